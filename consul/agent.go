@@ -6,10 +6,30 @@ package consul
 import (
 	"github.com/micro/go-micro/v2/registry"
 	"github.com/micro/go-micro/v2/server"
+	"net/http"
+	"time"
 )
 
 type ServiceName string
 
+// NodeState is state of service node in agent, used for introspection (add in v.1.0.5)
+type NodeState struct {
+	Node         *registry.Node `json:"node"`
+	Subsets      []string       `json:"subsets"`
+	Outstanding  int64          `json:"outstanding"`
+	Latency      string         `json:"latency"`
+	Ejected      bool           `json:"ejected"`
+	EjectedUntil time.Time      `json:"ejected_until"`
+	ForcedOut    bool           `json:"forced_out"`
+}
+
+// Route is information of request used for matching routing rule (add in v.1.0.5)
+type Route struct {
+	Header   http.Header
+	UserUUID string
+	UserType string
+}
+
 type Agent interface {
 	// method to refresh all service node list
 	// add in v.1.0.2
@@ -19,9 +39,33 @@ type Agent interface {
 	// add in v.1.0.2
 	ChangeServiceNodes(ServiceName) error
 
+	// method to start watching service node list with consul blocking query
+	// add in v.1.0.5
+	WatchAllServiceNodes() error
+
 	// get specific service node based on memory saved in change method
 	GetNextServiceNode(ServiceName) (*registry.Node, error)
 
+	// get specific service node matched with routing rule & return name of matched rule
+	// add in v.1.0.5
+	GetNextServiceNodeWithRoute(ServiceName, Route) (*registry.Node, string, error)
+
+	// start tracking call to service node & return closure reporting result of call
+	// add in v.1.0.5
+	TrackServiceNodeCall(ServiceName, *registry.Node) func(error)
+
+	// get state of all service nodes, such as selector stats & ejection
+	// add in v.1.0.5
+	GetServiceNodeStates() map[ServiceName][]NodeState
+
+	// force service node out of rotation, or put it back in rotation
+	// add in v.1.0.5
+	SetServiceNodeForcedOut(nodeID string, forcedOut bool) error
+
+	// check if consul is reachable from agent
+	// add in v.1.0.5
+	PingConsul() error
+
 	// change ttl health of specific check to fail
 	FailTTLHealth(checkID, note string) error
 
@@ -39,4 +83,8 @@ type Agent interface {
 	// get redis connection config from consul KV
 	// add in v.1.0.3
 	GetRedisConfigFromKV(key string) (RedisConfigKV, error)
+
+	// get outing policy rules from consul KV, empty policy is returned if KV doesn't exist
+	// add in v.1.0.5
+	GetOutingPolicyFromKV(key string) (OutingPolicyKV, error)
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/micro/go-micro/v2/client/selector"
 	"github.com/micro/go-micro/v2/registry"
 	"sync"
+	"time"
 )
 
 type _default struct {
@@ -17,11 +18,25 @@ type _default struct {
 	client   *api.Client
 	//  next      selector.Next                    // before v.1.0.2
 	//  nodes     []*registry.Node                 // before v.1.0.2
-	next      map[consul.ServiceName]selector.Next    // change in v.1.0.2
-	nodes     map[consul.ServiceName][]*registry.Node // change in v.1.0.2
-	services  []consul.ServiceName                    // add in v.1.0.2
-	nodeMutex sync.RWMutex                            // add in v.1.0.2
-	validator *validator.Validate                     // add in v.1.0.3
+	//  next      map[consul.ServiceName]selector.Next    // change in v.1.0.2, before v.1.0.5
+	subsets   map[consul.ServiceName]map[string]*nodeSubset // change from next in v.1.0.5
+	nodes     map[consul.ServiceName][]*registry.Node       // change in v.1.0.2
+	services  []consul.ServiceName                          // add in v.1.0.2
+	nodeMutex sync.RWMutex                                  // add in v.1.0.2
+	validator *validator.Validate                           // add in v.1.0.3
+
+	watchOnce      sync.Once     // add in v.1.0.5
+	watchWaitTime  time.Duration // add in v.1.0.5
+	resyncInterval time.Duration // add in v.1.0.5
+
+	strategies map[consul.ServiceName]StatsStrategy // add in v.1.0.5
+	stats      *NodeStats                           // add in v.1.0.5
+
+	outlierConfig OutlierConfig    // add in v.1.0.5
+	outliers      *outlierDetector // add in v.1.0.5
+
+	rules    []consul.RoutingRule // add in v.1.0.5
+	rulesKey string               // add in v.1.0.5
 }
 
 func Default(setters ...FieldSetter) *_default {
@@ -30,13 +45,19 @@ func Default(setters ...FieldSetter) *_default {
 
 func newDefault(setters ...FieldSetter) (h *_default) {
 	h = new(_default)
+	h.watchWaitTime = defaultWatchWaitTime
+	h.resyncInterval = defaultResyncInterval
+	h.strategies = map[consul.ServiceName]StatsStrategy{}
+	h.outlierConfig = defaultOutlierConfig
 	for _, setter := range setters {
 		setter(h)
 	}
-	h.next = map[consul.ServiceName]selector.Next{}
+	h.subsets = map[consul.ServiceName]map[string]*nodeSubset{}
 	h.nodes = map[consul.ServiceName][]*registry.Node{}
 	h.nodeMutex = sync.RWMutex{}
 	h.validator = validator.New()
+	h.stats = newNodeStats()
+	h.outliers = newOutlierDetector(h.outlierConfig)
 	return
 }
 
@@ -54,8 +75,43 @@ func Strategy(s selector.Strategy) FieldSetter {
 	}
 }
 
+// set strategy of specific service, Strategy field is used in service not set with this (add in v.1.0.5)
+func ServiceStrategy(service consul.ServiceName, s StatsStrategy) FieldSetter {
+	return func(d *_default) {
+		d.strategies[service] = s
+	}
+}
+
+// set config of passive outlier detection (add in v.1.0.5)
+func OutlierDetection(c OutlierConfig) FieldSetter {
+	return func(d *_default) {
+		d.outlierConfig = c
+	}
+}
+
+// set consul KV key of routing rules to watch, routing rule is not used if not set (add in v.1.0.5)
+func RoutingRulesKey(key string) FieldSetter {
+	return func(d *_default) {
+		d.rulesKey = key
+	}
+}
+
 func Services(s []consul.ServiceName) FieldSetter {
 	return func(d *_default) {
 		d.services = s
 	}
 }
+
+// set max wait time of blocking query in watch (add in v.1.0.5)
+func WatchWaitTime(t time.Duration) FieldSetter {
+	return func(d *_default) {
+		d.watchWaitTime = t
+	}
+}
+
+// set interval to resync all service nodes regardless of watch (add in v.1.0.5)
+func ResyncInterval(t time.Duration) FieldSetter {
+	return func(d *_default) {
+		d.resyncInterval = t
+	}
+}
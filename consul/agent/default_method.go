@@ -9,8 +9,13 @@ import (
 	"fmt"
 	"gateway/consul"
 	"github.com/hashicorp/consul/api"
+	"github.com/micro/go-micro/v2/client/selector"
 	"github.com/micro/go-micro/v2/registry"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const StatusMustBePassing = "Status==passing"
@@ -46,55 +51,116 @@ func (d *_default) ChangeServiceNodes(service consul.ServiceName) (err error) {
 
 // private method to handle business logic of changing specific service node list
 func (d *_default) changeServiceNodes(service consul.ServiceName) error {
-	checks, _, err := d.client.Health().Checks(string(service), &api.QueryOptions{Filter: StatusMustBePassing})
+	nodes, _, err := d.queryServiceNodes(service, nil)
 	if err != nil {
-		return errors.New(fmt.Sprintf("unable to query health checkes, err: %v", err))
+		return err
+	}
+
+	d.setServiceNodes(service, nodes)
+	return nil
+}
+
+// query passing service nodes from consul health endpoint, QueryOptions can contain WaitIndex for blocking query
+// change to use Health().Service instead of Health().Checks & Agent().Service in v.1.0.5
+func (d *_default) queryServiceNodes(service consul.ServiceName, opts *api.QueryOptions) ([]*registry.Node, *api.QueryMeta, error) {
+	entries, meta, err := d.client.Health().Service(string(service), "", true, opts)
+	if err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("unable to query service health, err: %v", err))
 	}
 
 	var nodes []*registry.Node
-	for _, check := range checks {
-		as, _, err := d.client.Agent().Service(check.ServiceID, nil)
-		if err != nil {
-			return errors.New(fmt.Sprintf("unable to query service, err: %v", err))
+	for _, entry := range entries {
+		as := entry.Service
+		var checkID string
+		for _, check := range entry.Checks {
+			if check.ServiceID == as.ID {
+				checkID = check.CheckID
+				break
+			}
+		}
+		md := nodeMetadata(checkID, as.Weights.Passing, as.Meta, as.Tags)
+		address := as.Address
+		if address == "" {
+			address = entry.Node.Address
 		}
-		var md = map[string]string{"CheckID": check.CheckID}
-		node := &registry.Node{Id: as.ID, Address: fmt.Sprintf("%s:%d", as.Address, as.Port), Metadata: md}
+		node := &registry.Node{Id: as.ID, Address: fmt.Sprintf("%s:%d", address, as.Port), Metadata: md}
 		nodes = append(nodes, node)
 	}
 
-	if !reflect.DeepEqual(d.nodes[service], nodes) {
-		d.nodes[service] = nodes
-		d.next[service] = d.Strategy([]*registry.Service{{Nodes: nodes}})
-	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
+	return nodes, meta, nil
+}
 
-	return nil
+// return metadata of registry.Node with service Weights, Meta & Tags (add in v.1.0.5)
+func nodeMetadata(checkID string, weight int, meta map[string]string, tags []string) map[string]string {
+	var md = map[string]string{"CheckID": checkID, "Weight": strconv.Itoa(weight)}
+	if weight, ok := meta["weight"]; ok {
+		md["Weight"] = weight
+	}
+	md["Version"] = meta["version"]
+	md["Tags"] = strings.Join(tags, ",")
+	return md
 }
 
-// move from tool/agent/default.go to agent/default_method.go
-// migrate change logic to changeServiceNodes method in v.1.0.2
-func (d *_default) GetNextServiceNode(service consul.ServiceName) (*registry.Node, error) {
-	d.nodeMutex.RLock()
-	defer d.nodeMutex.RUnlock()
-	
-	if !d.checkIfExistService(service) {
-		return nil, ErrUndefinedService
+// set service node list & selector next function if node list was changed (call with nodeMutex.Lock)
+func (d *_default) setServiceNodes(service consul.ServiceName, nodes []*registry.Node) (changed bool) {
+	if _, exist := d.nodes[service]; exist && reflect.DeepEqual(d.nodes[service], nodes) {
+		return
 	}
-	
-	if _, exist := d.nodes[service]; !exist {
-		_ = d.changeServiceNodes(service)
-		return nil, ErrUnavailableService
+
+	changed = true
+	d.nodes[service] = nodes
+	d.buildSubsets(service)
+
+	// prune outlier state of nodes leaving service (add in v.1.0.5)
+	nodeIDs := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		nodeIDs[node.Id] = true
 	}
+	d.outliers.prune(service, nodeIDs)
 
-	if len(d.nodes[service]) == 0 {
-		return nil, ErrAvailableNodeNotFound
+	// prune call stats of nodes not in any service, node may be in other service because stats are kept per node id
+	allNodeIDs := map[string]bool{}
+	for _, serviceNodes := range d.nodes {
+		for _, node := range serviceNodes {
+			allNodeIDs[node.Id] = true
+		}
 	}
+	d.stats.prune(allNodeIDs)
+	return
+}
 
-	selectedNode, err := d.next[service]()
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("unable to select node in selector, err: %v", err))
+// return strategy set for service in ServiceStrategy, or Strategy field if not set
+func (d *_default) strategyOf(service consul.ServiceName) selector.Strategy {
+	if s, ok := d.strategies[service]; ok {
+		return s(d.stats)
 	}
+	return d.Strategy
+}
+
+// move from tool/agent/default.go to agent/default_method.go
+// migrate change logic to changeServiceNodes method in v.1.0.2
+// select node in default subset which is not target of routing rule in v.1.0.5
+func (d *_default) GetNextServiceNode(service consul.ServiceName) (node *registry.Node, err error) {
+	node, _, err = d.GetNextServiceNodeWithRoute(service, consul.Route{})
+	return
+}
 
-	return selectedNode, nil
+// start tracking call to service node & return closure to call with result of call
+// tracked result is used in strategy using NodeStats, ex) LeastOutstanding, LatencyEWMA (add in v.1.0.5)
+// and also used in outlier detector ejecting node failed consecutively, instead of changing TTL health in consul
+func (d *_default) TrackServiceNodeCall(service consul.ServiceName, node *registry.Node) func(error) {
+	start := time.Now()
+	d.stats.start(node.Id)
+
+	return func(err error) {
+		d.stats.finish(node.Id, time.Since(start), err)
+
+		d.nodeMutex.RLock()
+		total := len(d.nodes[service])
+		d.nodeMutex.RUnlock()
+		d.outliers.record(service, node.Id, total, err)
+	}
 }
 
 // check if _default.services array contain srv parameter
@@ -110,6 +176,14 @@ func (d *_default) checkIfExistService(srv consul.ServiceName) (exist bool) {
 	return
 }
 
+// check if consul is reachable by querying raft leader (add in v.1.0.5)
+func (d *_default) PingConsul() (err error) {
+	if _, err = d.client.Status().Leader(); err != nil {
+		err = errors.New(fmt.Sprintf("unable to query consul leader, err: %v", err))
+	}
+	return
+}
+
 // move from tool/agent/default.go to agent/default_method.go in v.1.0.2
 func (d *_default) FailTTLHealth(checkID, note string) (err error) {
 	return d.client.Agent().FailTTL(checkID, note)
@@ -127,12 +201,40 @@ func (d *_default) GetRedisConfigFromKV(key string) (conf consul.RedisConfigKV,
 		return
 	}
 
-	if err = json.Unmarshal(kv.Value, &conf); err != nil {
+	if kv == nil {
+		err = errors.New(fmt.Sprintf("%s KV is not exist in consul", key))
+		return
+	}
+
+	err = d.unmarshalKV(key, kv.Value, &conf)
+	return
+}
+
+// get outing policy from consul KV, policy doesn't have any rule if KV doesn't exist (add in v.1.0.5)
+func (d *_default) GetOutingPolicyFromKV(key string) (conf consul.OutingPolicyKV, err error) {
+	kv, _, err := d.client.KV().Get(key, nil)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to get %s KV from consul, err: %v", key, err.Error()))
+		return
+	}
+
+	if kv == nil {
+		return
+	}
+
+	err = d.unmarshalKV(key, kv.Value, &conf)
+	return
+}
+
+
+// unmarshal KV value into struct & validate it (separate from GetRedisConfigFromKV in v.1.0.5)
+func (d *_default) unmarshalKV(key string, value []byte, conf interface{}) (err error) {
+	if err = json.Unmarshal(value, conf); err != nil {
 		err = errors.New(fmt.Sprintf("error occurs while unmarshal KV value into struct, err: %v", err.Error()))
 		return
 	}
 
-	if err = d.validator.Struct(&conf); err != nil {
+	if err = d.validator.Struct(conf); err != nil {
 		err = errors.New(fmt.Sprintf("invalid %s KV value, err: %v", key, err.Error()))
 		return
 	}
@@ -0,0 +1,68 @@
+// add file in v.1.0.5
+// default_method_state.go is file to declare method inspecting or changing state of service node in default struct
+
+package agent
+
+import (
+	"gateway/consul"
+	"sort"
+)
+
+// get state of all service nodes with call stats, subsets & ejection in outlier detector
+func (d *_default) GetServiceNodeStates() map[consul.ServiceName][]consul.NodeState {
+	d.nodeMutex.RLock()
+	defer d.nodeMutex.RUnlock()
+
+	var states = map[consul.ServiceName][]consul.NodeState{}
+	for service, nodes := range d.nodes {
+		states[service] = []consul.NodeState{}
+		for _, node := range nodes {
+			ejected, ejectedUntil, forcedOut := d.outliers.state(node.Id)
+			states[service] = append(states[service], consul.NodeState{
+				Node:         node,
+				Subsets:      d.subsetsOf(service, node.Id),
+				Outstanding:  d.stats.Outstanding(node.Id),
+				Latency:      d.stats.Latency(node.Id).String(),
+				Ejected:      ejected,
+				EjectedUntil: ejectedUntil,
+				ForcedOut:    forcedOut,
+			})
+		}
+	}
+	return states
+}
+
+// force service node out of rotation, or put it back in rotation
+func (d *_default) SetServiceNodeForcedOut(nodeID string, forcedOut bool) error {
+	d.nodeMutex.RLock()
+	defer d.nodeMutex.RUnlock()
+
+	for service, nodes := range d.nodes {
+		for _, node := range nodes {
+			if node.Id == nodeID {
+				d.outliers.setForcedOut(service, nodeID, forcedOut)
+				return nil
+			}
+		}
+	}
+	return ErrUndefinedNode
+}
+
+// return sorted keys of subsets containing node, default subset is returned as DefaultRouteName (call with nodeMutex.RLock)
+func (d *_default) subsetsOf(service consul.ServiceName, nodeID string) (keys []string) {
+	keys = []string{}
+	for key, subset := range d.subsets[service] {
+		for _, node := range subset.nodes {
+			if node.Id != nodeID {
+				continue
+			}
+			if key == defaultSubset {
+				key = DefaultRouteName
+			}
+			keys = append(keys, key)
+			break
+		}
+	}
+	sort.Strings(keys)
+	return
+}
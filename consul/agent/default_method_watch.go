@@ -0,0 +1,85 @@
+// add file in v.1.0.5
+// default_method_watch.go is file to declare method watching service nodes with consul blocking query
+
+package agent
+
+import (
+	"gateway/consul"
+	"github.com/hashicorp/consul/api"
+	log "github.com/micro/go-micro/v2/logger"
+	"time"
+)
+
+const (
+	defaultWatchWaitTime  = time.Minute * 5
+	defaultResyncInterval = time.Minute * 10
+	minWatchBackoff       = time.Second
+	maxWatchBackoff       = time.Minute
+)
+
+// start goroutine watching health endpoint of each service & resyncing all service nodes periodically
+// it is safe to call this method multiple times, watchers are started only once
+func (d *_default) WatchAllServiceNodes() (_ error) {
+	d.watchOnce.Do(func() {
+		for _, service := range d.services {
+			go d.watchServiceNodes(service)
+		}
+		go d.resyncAllServiceNodes()
+		if d.rulesKey != "" {
+			go d.watchRoutingRules()
+		}
+		log.Infof("start watching service nodes in consul!! (services: %v)", d.services)
+	})
+	return
+}
+
+// watch passing nodes of specific service with blocking query & change node list as soon as index is changed
+func (d *_default) watchServiceNodes(service consul.ServiceName) {
+	var index uint64
+	var backoff = minWatchBackoff
+
+	for {
+		opts := &api.QueryOptions{WaitIndex: index, WaitTime: d.watchWaitTime}
+		nodes, meta, err := d.queryServiceNodes(service, opts)
+		if err != nil {
+			log.Errorf("unable to watch service nodes, retry after %s, service: %s, err: %v", backoff.String(), service, err)
+			time.Sleep(backoff)
+			backoff = nextWatchBackoff(backoff)
+			continue
+		}
+		backoff = minWatchBackoff
+
+		// reset index if it goes backwards, or it is not greater than zero (see consul blocking query doc)
+		switch {
+		case meta.LastIndex < index, meta.LastIndex == 0:
+			index = 0
+		default:
+			index = meta.LastIndex
+		}
+
+		d.nodeMutex.Lock()
+		changed := d.setServiceNodes(service, nodes)
+		d.nodeMutex.Unlock()
+
+		if changed {
+			log.Infof("service nodes changed by consul watch!! (service: %s, node num: %d, index: %d)", service, len(nodes), index)
+		}
+	}
+}
+
+// return backoff doubled from previous backoff of failed watch, up to max watch backoff
+func nextWatchBackoff(backoff time.Duration) time.Duration {
+	if backoff *= 2; backoff > maxWatchBackoff {
+		backoff = maxWatchBackoff
+	}
+	return backoff
+}
+
+// call ChangeAllServiceNodes every resync interval in case of missing change in watch
+func (d *_default) resyncAllServiceNodes() {
+	for range time.Tick(d.resyncInterval) {
+		if err := d.ChangeAllServiceNodes(); err != nil {
+			log.Errorf("unable to resync all service nodes, err: %v", err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"gateway/consul"
+	"github.com/hashicorp/consul/api"
+	"github.com/micro/go-micro/v2/client/selector"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHealth is consul health endpoint of single service answering blocking query with index
+type fakeHealth struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	index   uint64
+	entries []*api.ServiceEntry
+	closed  bool
+}
+
+func newFakeHealth() *fakeHealth {
+	f := &fakeHealth{index: 1}
+	f.cond = sync.NewCond(&f.mutex)
+	return f
+}
+
+// set passing service entries & increase index, blocked queries are answered
+func (f *fakeHealth) setServices(services ...*api.AgentService) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.entries = nil
+	for _, service := range services {
+		check := &api.HealthCheck{CheckID: fmt.Sprintf("service:%s", service.ID), ServiceID: service.ID}
+		f.entries = append(f.entries, &api.ServiceEntry{Node: &api.Node{Address: "10.0.0.1"}, Service: service, Checks: api.HealthChecks{check}})
+	}
+	f.index++
+	f.cond.Broadcast()
+}
+
+// answer all blocked queries, so that server can be closed
+func (f *fakeHealth) close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.closed = true
+	f.cond.Broadcast()
+}
+
+func (f *fakeHealth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	waitIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+
+	f.mutex.Lock()
+	for waitIndex != 0 && waitIndex >= f.index && !f.closed {
+		f.cond.Wait()
+	}
+	index, entries := f.index, f.entries
+	f.mutex.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func TestNextWatchBackoff(t *testing.T) {
+	backoff := minWatchBackoff
+	for _, expected := range []time.Duration{time.Second * 2, time.Second * 4, time.Second * 8, time.Second * 16, time.Second * 32, time.Minute, time.Minute} {
+		backoff = nextWatchBackoff(backoff)
+		assert.Equal(t, expected, backoff)
+	}
+}
+
+func TestWatchServiceNodes(t *testing.T) {
+	health := newFakeHealth()
+	health.setServices(&api.AgentService{ID: "auth-1", Port: 10001, Weights: api.AgentWeights{Passing: 1}})
+	server := httptest.NewServer(health)
+	defer server.Close()
+	defer health.close()
+
+	client, err := api.NewClient(&api.Config{Address: server.Listener.Addr().String()})
+	assert.NoError(t, err)
+	d := Default(Client(client), Services([]consul.ServiceName{"auth"}), Strategy(selector.RoundRobin))
+	go d.watchServiceNodes("auth")
+
+	nodeIDs := func() (ids []string) {
+		d.nodeMutex.RLock()
+		defer d.nodeMutex.RUnlock()
+		for _, node := range d.nodes["auth"] {
+			ids = append(ids, node.Id)
+		}
+		return
+	}
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual([]string{"auth-1"}, nodeIDs()) }, time.Second, time.Millisecond*10)
+
+	// change of nodes is applied as soon as blocking query returns
+	health.setServices(
+		&api.AgentService{ID: "auth-2", Address: "10.0.0.2", Port: 10002, Tags: []string{"canary"}, Meta: map[string]string{"version": "1.0.5", "weight": "3"}},
+		&api.AgentService{ID: "auth-1", Port: 10001, Weights: api.AgentWeights{Passing: 1}},
+	)
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual([]string{"auth-1", "auth-2"}, nodeIDs()) }, time.Second, time.Millisecond*10)
+
+	d.nodeMutex.RLock()
+	first, second := d.nodes["auth"][0], d.nodes["auth"][1]
+	d.nodeMutex.RUnlock()
+	assert.Equal(t, "10.0.0.1:10001", first.Address, "node address is used if service address is empty")
+	assert.Equal(t, map[string]string{"CheckID": "service:auth-1", "Weight": "1", "Version": "", "Tags": ""}, first.Metadata)
+	assert.Equal(t, "10.0.0.2:10002", second.Address)
+	assert.Equal(t, map[string]string{"CheckID": "service:auth-2", "Weight": "3", "Version": "1.0.5", "Tags": "canary"}, second.Metadata)
+}
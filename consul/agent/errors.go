@@ -9,4 +9,5 @@ var (
 	ErrAvailableNodeNotFound = errors.New("there is no currently available service node")
 	ErrUndefinedService = errors.New("undefined service, please put in agent.Services if you want to use")
 	ErrUnavailableService = errors.New("unavailable service, maybe some error occurred when change service nodes")
+	ErrUndefinedNode = errors.New("undefined node, there is no node with id in any service") // add in v.1.0.5
 )
@@ -0,0 +1,172 @@
+// add file in v.1.0.5
+// file.go is file to declare agent reading service nodes & KV from YAML or JSON file, used for local development
+//
+// example of registry file (YAML)
+//  services:
+//    DMS.SMS.v1.service.auth:
+//      - id: auth-local
+//        address: 127.0.0.1:10010
+//        version: "1.0"
+//        tags: [local]
+//        weight: 1
+//  kv:
+//    redis/gateway/local: '{"host": "127.0.0.1", "port": 6379, "DB": 0}'
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/consul"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/registry"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// interval to check modification time of registry file
+const defaultFileWatchInterval = time.Second * 2
+
+type _file struct {
+	*_memory
+	path      string
+	modTime   time.Time
+	fileMutex sync.Mutex
+}
+
+// entity of registry file
+type registryFile struct {
+	Services map[string][]registryFileNode `json:"services" yaml:"services"`
+	KV       map[string]string             `json:"kv" yaml:"kv"`
+}
+
+type registryFileNode struct {
+	ID      string            `json:"id" yaml:"id"`
+	Address string            `json:"address" yaml:"address"`
+	Version string            `json:"version" yaml:"version"`
+	Tags    []string          `json:"tags" yaml:"tags"`
+	Weight  int               `json:"weight" yaml:"weight"`
+	Meta    map[string]string `json:"meta" yaml:"meta"`
+}
+
+func File(path string, setters ...FieldSetter) *_file {
+	return newFile(path, setters...)
+}
+
+func newFile(path string, setters ...FieldSetter) (f *_file) {
+	f = &_file{_memory: newMemory(setters...), path: path}
+	f.fileMutex = sync.Mutex{}
+
+	if err := f.loadFile(); err != nil {
+		log.Errorf("unable to load registry file, path: %s, err: %v", path, err)
+	}
+	return
+}
+
+// load registry file again & change all service nodes
+func (f *_file) ChangeAllServiceNodes() error {
+	return f.loadFile()
+}
+
+// load registry file again & change all service nodes, because file can't be loaded per service
+func (f *_file) ChangeServiceNodes(_ consul.ServiceName) error {
+	return f.loadFile()
+}
+
+// start goroutine checking modification time of registry file & loading it again if file was modified
+func (f *_file) WatchAllServiceNodes() (_ error) {
+	f.watchOnce.Do(func() {
+		go func() {
+			for range time.Tick(defaultFileWatchInterval) {
+				if !f.isFileModified() {
+					continue
+				}
+				if err := f.loadFile(); err != nil {
+					log.Errorf("unable to reload registry file, path: %s, err: %v", f.path, err)
+					continue
+				}
+				log.Infof("service nodes changed by registry file!! (path: %s)", f.path)
+			}
+		}()
+	})
+	return
+}
+
+// check if registry file was modified after last loading
+func (f *_file) isFileModified() bool {
+	f.fileMutex.Lock()
+	defer f.fileMutex.Unlock()
+
+	stat, err := os.Stat(f.path)
+	return err == nil && stat.ModTime().After(f.modTime)
+}
+
+// read registry file & replace nodes and KV in memory with content of file
+func (f *_file) loadFile() (err error) {
+	f.fileMutex.Lock()
+	defer f.fileMutex.Unlock()
+
+	stat, err := os.Stat(f.path)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to get stat of registry file, err: %v", err))
+		return
+	}
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to read registry file, err: %v", err))
+		return
+	}
+
+	var rf registryFile
+	switch filepath.Ext(f.path) {
+	case ".json":
+		err = json.Unmarshal(content, &rf)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &rf)
+	default:
+		err = errors.New(fmt.Sprintf("unsupported extension of registry file, path: %s", f.path))
+	}
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to unmarshal registry file, err: %v", err))
+		return
+	}
+
+	var registered = map[consul.ServiceName][]*registry.Node{}
+	for service, fileNodes := range rf.Services {
+		for _, fn := range fileNodes {
+			meta := map[string]string{"version": fn.Version}
+			for k, v := range fn.Meta {
+				meta[k] = v
+			}
+			if fn.Weight == 0 {
+				fn.Weight = defaultNodeWeight
+			}
+			md := nodeMetadata(fmt.Sprintf("service:%s", fn.ID), fn.Weight, meta, fn.Tags)
+			node := &registry.Node{Id: fn.ID, Address: fn.Address, Metadata: md}
+			registered[consul.ServiceName(service)] = append(registered[consul.ServiceName(service)], node)
+		}
+	}
+
+	var kv = map[string][]byte{}
+	for key, value := range rf.KV {
+		kv[key] = []byte(value)
+	}
+
+	f.memMutex.Lock()
+	f.registered = registered
+	f.kv = kv
+	f.memMutex.Unlock()
+	f.modTime = stat.ModTime()
+
+	if f.rulesKey != "" {
+		if err = f.changeRoutingRules(kv[f.rulesKey]); err != nil {
+			return
+		}
+	}
+	return f._memory.ChangeAllServiceNodes()
+}
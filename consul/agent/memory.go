@@ -0,0 +1,192 @@
+// add file in v.1.0.5
+// memory.go is file to declare in-memory agent not connecting to consul, used for test & local development
+// unlike mock, it selects node with real strategy and supports health failure of node
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"gateway/consul"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/server"
+	"sort"
+	"sync"
+)
+
+type _memory struct {
+	*_default
+	memMutex   sync.RWMutex
+	registered map[consul.ServiceName][]*registry.Node
+	failing    map[string]string // note of failing check per check id
+	kv         map[string][]byte
+}
+
+func Memory(setters ...FieldSetter) *_memory {
+	return newMemory(setters...)
+}
+
+func newMemory(setters ...FieldSetter) (m *_memory) {
+	m = &_memory{_default: newDefault(setters...)}
+	m.memMutex = sync.RWMutex{}
+	m.registered = map[consul.ServiceName][]*registry.Node{}
+	m.failing = map[string]string{}
+	m.kv = map[string][]byte{}
+
+	// set empty node list, so that default agent doesn't query to consul
+	m.nodeMutex.Lock()
+	for _, service := range m.services {
+		m.setServiceNodes(service, nil)
+	}
+	m.nodeMutex.Unlock()
+	return
+}
+
+// register node of service in memory, CheckID in metadata is set to "service:{node id}" if not exist
+func (m *_memory) RegisterServiceNode(service consul.ServiceName, node *registry.Node) {
+	if node.Metadata == nil {
+		node.Metadata = map[string]string{}
+	}
+	if node.Metadata["CheckID"] == "" {
+		node.Metadata["CheckID"] = fmt.Sprintf("service:%s", node.Id)
+	}
+
+	m.memMutex.Lock()
+	var nodes []*registry.Node
+	for _, registered := range m.registered[service] {
+		if registered.Id != node.Id {
+			nodes = append(nodes, registered)
+		}
+	}
+	m.registered[service] = append(nodes, node)
+	m.memMutex.Unlock()
+
+	_ = m.ChangeServiceNodes(service)
+}
+
+// deregister node of service in memory
+func (m *_memory) DeregisterServiceNode(service consul.ServiceName, nodeID string) {
+	m.memMutex.Lock()
+	var nodes []*registry.Node
+	for _, registered := range m.registered[service] {
+		if registered.Id != nodeID {
+			nodes = append(nodes, registered)
+		}
+	}
+	m.registered[service] = nodes
+	m.memMutex.Unlock()
+
+	_ = m.ChangeServiceNodes(service)
+}
+
+// put value of key in memory KV, routing rules are changed if key is routing rules key
+func (m *_memory) PutKV(key string, value []byte) (err error) {
+	m.memMutex.Lock()
+	m.kv[key] = value
+	m.memMutex.Unlock()
+
+	if m.rulesKey != "" && key == m.rulesKey {
+		err = m.changeRoutingRules(value)
+	}
+	return
+}
+
+// set registered nodes of all services which are not failing as service nodes
+func (m *_memory) ChangeAllServiceNodes() (_ error) {
+	for _, service := range m.services {
+		_ = m.ChangeServiceNodes(service)
+	}
+	return
+}
+
+// set registered nodes of service which are not failing as service nodes
+func (m *_memory) ChangeServiceNodes(service consul.ServiceName) (_ error) {
+	m.memMutex.RLock()
+	var nodes []*registry.Node
+	for _, node := range m.registered[service] {
+		if _, ok := m.failing[node.Metadata["CheckID"]]; !ok {
+			nodes = append(nodes, node)
+		}
+	}
+	m.memMutex.RUnlock()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
+
+	m.nodeMutex.Lock()
+	m.setServiceNodes(service, nodes)
+	m.nodeMutex.Unlock()
+	return
+}
+
+// there is nothing to watch, because node change in memory is applied immediately
+func (m *_memory) WatchAllServiceNodes() (_ error) {
+	return
+}
+
+// there is no consul to ping, so memory agent is always reachable
+func (m *_memory) PingConsul() (_ error) {
+	return
+}
+
+// change health of check to fail & exclude node of check from service nodes
+func (m *_memory) FailTTLHealth(checkID, note string) (_ error) {
+	m.memMutex.Lock()
+	m.failing[checkID] = note
+	m.memMutex.Unlock()
+
+	return m.ChangeAllServiceNodes()
+}
+
+// change health of check to pass & include node of check in service nodes again
+func (m *_memory) PassTTLHealth(checkID, note string) (_ error) {
+	m.memMutex.Lock()
+	delete(m.failing, checkID)
+	m.memMutex.Unlock()
+
+	return m.ChangeAllServiceNodes()
+}
+
+// there is no consul to register, so return closure only logging
+func (m *_memory) ServiceNodeRegistry(s server.Server) func() error {
+	return func() (_ error) {
+		log.Infof("skip to registry service in memory agent!! (service name: %s)", s.Options().Name)
+		return
+	}
+}
+
+// there is no consul to deregister, so return closure only logging
+func (m *_memory) ServiceNodeDeregistry(s server.Server) func() error {
+	return func() (_ error) {
+		log.Infof("skip to deregistry service in memory agent!! (service name: %s)", s.Options().Name)
+		return
+	}
+}
+
+// get redis connection config from memory KV
+func (m *_memory) GetRedisConfigFromKV(key string) (conf consul.RedisConfigKV, err error) {
+	m.memMutex.RLock()
+	value, ok := m.kv[key]
+	m.memMutex.RUnlock()
+
+	if !ok {
+		err = errors.New(fmt.Sprintf("%s KV is not exist in memory", key))
+		return
+	}
+
+	err = m.unmarshalKV(key, value, &conf)
+	return
+}
+
+// get outing policy from memory KV, policy doesn't have any rule if KV doesn't exist (add in v.1.0.5)
+func (m *_memory) GetOutingPolicyFromKV(key string) (conf consul.OutingPolicyKV, err error) {
+	m.memMutex.RLock()
+	value, ok := m.kv[key]
+	m.memMutex.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	err = m.unmarshalKV(key, value, &conf)
+	return
+}
@@ -26,11 +26,36 @@ func (m _mock) ChangeServiceNodes(service consul.ServiceName) error {
 	return m.mock.Called().Error(0)
 }
 
+func (m _mock) WatchAllServiceNodes() error {
+	return m.mock.Called().Error(0)
+}
+
 func (m _mock) GetNextServiceNode(service consul.ServiceName) (*registry.Node, error) {
 	args := m.mock.Called(service)
 	return args.Get(0).(*registry.Node), args.Error(1)
 }
 
+func (m _mock) TrackServiceNodeCall(service consul.ServiceName, node *registry.Node) func(error) {
+	return m.mock.Called(service, node).Get(0).(func(error))
+}
+
+func (m _mock) GetNextServiceNodeWithRoute(service consul.ServiceName, route consul.Route) (*registry.Node, string, error) {
+	args := m.mock.Called(service, route)
+	return args.Get(0).(*registry.Node), args.String(1), args.Error(2)
+}
+
+func (m _mock) GetServiceNodeStates() map[consul.ServiceName][]consul.NodeState {
+	return m.mock.Called().Get(0).(map[consul.ServiceName][]consul.NodeState)
+}
+
+func (m _mock) SetServiceNodeForcedOut(nodeID string, forcedOut bool) error {
+	return m.mock.Called(nodeID, forcedOut).Error(0)
+}
+
+func (m _mock) PingConsul() error {
+	return m.mock.Called().Error(0)
+}
+
 func (m _mock) FailTTLHealth(checkID, note string) error {
 	return m.mock.Called().Error(0)
 }
@@ -51,3 +76,8 @@ func (m _mock) GetRedisConfigFromKV(key string) (consul.RedisConfigKV, error) {
 	args := m.mock.Called(key)
 	return args.Get(0).(consul.RedisConfigKV), args.Error(1)
 }
+
+func (m _mock) GetOutingPolicyFromKV(key string) (consul.OutingPolicyKV, error) {
+	args := m.mock.Called(key)
+	return args.Get(0).(consul.OutingPolicyKV), args.Error(1)
+}
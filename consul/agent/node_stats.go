@@ -0,0 +1,112 @@
+// add file in v.1.0.5
+// node_stats.go is file to declare NodeStats struct collecting call result of service node to use in strategy
+
+package agent
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// decay time of latency EWMA, sample older than this time affect less than 1/e to EWMA
+	ewmaDecayTime = time.Second * 10
+
+	// latency recorded instead of real latency when call returns error, so that failed node receive less traffic
+	errorPenaltyLatency = time.Second * 5
+)
+
+// NodeStats is struct having call statistics per service node id
+type NodeStats struct {
+	mutex sync.RWMutex
+	stats map[string]*nodeStat
+}
+
+type nodeStat struct {
+	outstanding int64
+	ewma        float64 // latency EWMA in nanoseconds
+	lastUpdate  time.Time
+}
+
+func newNodeStats() *NodeStats {
+	return &NodeStats{
+		mutex: sync.RWMutex{},
+		stats: map[string]*nodeStat{},
+	}
+}
+
+// get stat of node & create if not exist (call with mutex.Lock)
+func (s *NodeStats) getOrCreate(nodeID string) *nodeStat {
+	if _, ok := s.stats[nodeID]; !ok {
+		s.stats[nodeID] = &nodeStat{}
+	}
+	return s.stats[nodeID]
+}
+
+// increase outstanding request count of node
+func (s *NodeStats) start(nodeID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.getOrCreate(nodeID).outstanding++
+}
+
+// decrease outstanding request count of node & update latency EWMA with call result
+func (s *NodeStats) finish(nodeID string, latency time.Duration, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stat := s.getOrCreate(nodeID)
+	if stat.outstanding > 0 {
+		stat.outstanding--
+	}
+
+	if err != nil && latency < errorPenaltyLatency {
+		latency = errorPenaltyLatency
+	}
+
+	now := time.Now()
+	if stat.lastUpdate.IsZero() {
+		stat.ewma = float64(latency)
+	} else {
+		beta := math.Exp(-float64(now.Sub(stat.lastUpdate)) / float64(ewmaDecayTime))
+		stat.ewma = stat.ewma*beta + float64(latency)*(1-beta)
+	}
+	stat.lastUpdate = now
+}
+
+// delete stat of nodes not in nodeIDs, nodeIDs must have nodes of every service because stat is not kept per service
+// stat of node deleted while it is called is created again in finish, and it is deleted in next prune
+func (s *NodeStats) prune(nodeIDs map[string]bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for nodeID := range s.stats {
+		if !nodeIDs[nodeID] {
+			delete(s.stats, nodeID)
+		}
+	}
+}
+
+// Outstanding return count of request which is being processed in node
+func (s *NodeStats) Outstanding(nodeID string) int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if stat, ok := s.stats[nodeID]; ok {
+		return stat.outstanding
+	}
+	return 0
+}
+
+// Latency return latency EWMA of node, return zero if node was never called
+func (s *NodeStats) Latency(nodeID string) time.Duration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if stat, ok := s.stats[nodeID]; ok {
+		return time.Duration(stat.ewma)
+	}
+	return 0
+}
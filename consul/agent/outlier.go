@@ -0,0 +1,186 @@
+// add file in v.1.0.5
+// outlier.go is file to declare outlierDetector ejecting service node locally with result of call to node
+
+package agent
+
+import (
+	"gateway/consul"
+	"sync"
+	"time"
+)
+
+// OutlierConfig is config about passive outlier detection of service node
+type OutlierConfig struct {
+	// number of consecutive failed call to eject node
+	ConsecutiveFailures int
+
+	// ejection time of first ejection, ejection time grows as many as failed probe after that
+	BaseEjectionTime time.Duration
+
+	// max ejection time regardless of number of ejection
+	MaxEjectionTime time.Duration
+
+	// max percentage of ejected nodes in service, node is not ejected if it exceeds this value
+	MaxEjectionPercent int
+
+	// time to wait result of half-open probe, another probe is allowed after this time
+	ProbeTimeout time.Duration
+}
+
+var defaultOutlierConfig = OutlierConfig{
+	ConsecutiveFailures: 5,
+	BaseEjectionTime:    time.Second * 30,
+	MaxEjectionTime:     time.Minute * 5,
+	MaxEjectionPercent:  50,
+	ProbeTimeout:        time.Second * 10,
+}
+
+type outlierDetector struct {
+	config OutlierConfig
+	mutex  sync.Mutex
+	states map[string]*outlierState
+}
+
+type outlierState struct {
+	service       consul.ServiceName
+	failures      int       // number of consecutive failed call
+	ejected       bool      // whether node is ejected now
+	ejectionCount int       // number of ejection without successful probe, used to grow ejection time
+	ejectedUntil  time.Time // time node can be probed
+	probeStarted  time.Time // start time of half-open probe, zero if not probing
+	forcedOut     bool      // whether node is forced out of rotation by admin (add in v.1.0.5)
+}
+
+func newOutlierDetector(config OutlierConfig) *outlierDetector {
+	return &outlierDetector{
+		config: config,
+		mutex:  sync.Mutex{},
+		states: map[string]*outlierState{},
+	}
+}
+
+// return if node can be selected, one call is allowed as half-open probe to ejected node after ejection time
+func (o *outlierDetector) available(nodeID string) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	state, ok := o.states[nodeID]
+	switch {
+	case !ok:
+		return true
+	case state.forcedOut:
+		return false
+	case !state.ejected:
+		return true
+	}
+
+	now := time.Now()
+	switch {
+	case now.Before(state.ejectedUntil):
+		return false
+	case !state.probeStarted.IsZero() && now.Sub(state.probeStarted) < o.config.ProbeTimeout:
+		return false
+	default:
+		state.probeStarted = now
+		return true
+	}
+}
+
+// record result of call to node & eject or reinstate node, total is number of nodes in service
+func (o *outlierDetector) record(service consul.ServiceName, nodeID string, total int, err error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if _, ok := o.states[nodeID]; !ok {
+		o.states[nodeID] = &outlierState{service: service}
+	}
+	state := o.states[nodeID]
+
+	if err == nil {
+		switch {
+		case state.ejected && !state.probeStarted.IsZero():
+			// reinstate node if probe succeed
+			state.ejected = false
+			state.ejectionCount = 0
+			state.probeStarted = time.Time{}
+			state.failures = 0
+		case !state.ejected:
+			state.failures = 0
+		}
+		return
+	}
+
+	state.failures++
+	switch {
+	case state.ejected && !state.probeStarted.IsZero():
+		// probe failed, eject again with longer ejection time
+		o.eject(state)
+	case !state.ejected && state.failures >= o.config.ConsecutiveFailures && o.canEject(service, total):
+		o.eject(state)
+	}
+}
+
+// eject node for ejection time growing with ejection count (call with mutex.Lock)
+func (o *outlierDetector) eject(state *outlierState) {
+	state.ejected = true
+	state.ejectionCount++
+	state.probeStarted = time.Time{}
+
+	ejectionTime := o.config.BaseEjectionTime * time.Duration(state.ejectionCount)
+	if ejectionTime > o.config.MaxEjectionTime {
+		ejectionTime = o.config.MaxEjectionTime
+	}
+	state.ejectedUntil = time.Now().Add(ejectionTime)
+}
+
+// check if ejecting one more node in service not exceed max ejection percent (call with mutex.Lock)
+func (o *outlierDetector) canEject(service consul.ServiceName, total int) bool {
+	if total == 0 {
+		return false
+	}
+
+	ejected := 0
+	for _, state := range o.states {
+		if state.service == service && state.ejected {
+			ejected++
+		}
+	}
+	return (ejected+1)*100 <= total*o.config.MaxEjectionPercent
+}
+
+// force node out of rotation regardless of call result, or put it back
+func (o *outlierDetector) setForcedOut(service consul.ServiceName, nodeID string, forcedOut bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if _, ok := o.states[nodeID]; !ok {
+		o.states[nodeID] = &outlierState{service: service}
+	}
+	o.states[nodeID].forcedOut = forcedOut
+}
+
+// return ejection state of node, ejectedUntil is zero if not ejected
+func (o *outlierDetector) state(nodeID string) (ejected bool, ejectedUntil time.Time, forcedOut bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if state, ok := o.states[nodeID]; ok {
+		ejected, forcedOut = state.ejected, state.forcedOut
+		if ejected {
+			ejectedUntil = state.ejectedUntil
+		}
+	}
+	return
+}
+
+// delete state of nodes in service not existing in nodes any more, so that removed node is not counted in canEject
+func (o *outlierDetector) prune(service consul.ServiceName, nodeIDs map[string]bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for nodeID, state := range o.states {
+		if state.service == service && !nodeIDs[nodeID] {
+			delete(o.states, nodeID)
+		}
+	}
+}
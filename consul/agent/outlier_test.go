@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var errCallFailed = errors.New("call failed")
+
+var testOutlierConfig = OutlierConfig{
+	ConsecutiveFailures: 3,
+	BaseEjectionTime:    time.Minute,
+	MaxEjectionTime:     time.Minute * 3,
+	MaxEjectionPercent:  50,
+	ProbeTimeout:        time.Second * 10,
+}
+
+func TestOutlierEjection(t *testing.T) {
+	o := newOutlierDetector(testOutlierConfig)
+
+	o.record("auth", "a", 2, errCallFailed)
+	o.record("auth", "a", 2, errCallFailed)
+	o.record("auth", "a", 2, nil) // success reset consecutive failures
+	o.record("auth", "a", 2, errCallFailed)
+	o.record("auth", "a", 2, errCallFailed)
+	assert.True(t, o.available("a"))
+
+	o.record("auth", "a", 2, errCallFailed)
+	assert.False(t, o.available("a"))
+	ejected, ejectedUntil, _ := o.state("a")
+	assert.True(t, ejected)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), ejectedUntil, time.Second)
+	assert.True(t, o.available("b"), "node never called is available")
+}
+
+func TestOutlierMaxEjectionPercent(t *testing.T) {
+	o := newOutlierDetector(testOutlierConfig)
+	for i := 0; i < 3; i++ {
+		o.record("auth", "a", 2, errCallFailed)
+		o.record("auth", "b", 2, errCallFailed)
+		o.record("outing", "c", 1, errCallFailed)
+	}
+
+	// only one of two nodes can be ejected in 50%, and node of single node service can't be ejected
+	assert.False(t, o.available("a"))
+	assert.True(t, o.available("b"))
+	assert.True(t, o.available("c"))
+}
+
+func TestOutlierProbe(t *testing.T) {
+	o := newOutlierDetector(testOutlierConfig)
+	for i := 0; i < 3; i++ {
+		o.record("auth", "a", 2, errCallFailed)
+	}
+
+	// pass ejection time, only one call is allowed as probe until probe timeout
+	o.states["a"].ejectedUntil = time.Now().Add(-time.Second)
+	assert.True(t, o.available("a"))
+	assert.False(t, o.available("a"))
+
+	// failed probe eject node again with longer ejection time
+	o.record("auth", "a", 2, errCallFailed)
+	_, ejectedUntil, _ := o.state("a")
+	assert.WithinDuration(t, time.Now().Add(time.Minute*2), ejectedUntil, time.Second)
+
+	// ejection time doesn't exceed max ejection time
+	for i := 0; i < 3; i++ {
+		o.states["a"].ejectedUntil = time.Now().Add(-time.Second)
+		assert.True(t, o.available("a"))
+		o.record("auth", "a", 2, errCallFailed)
+	}
+	_, ejectedUntil, _ = o.state("a")
+	assert.WithinDuration(t, time.Now().Add(time.Minute*3), ejectedUntil, time.Second)
+
+	// successful probe reinstate node
+	o.states["a"].ejectedUntil = time.Now().Add(-time.Second)
+	assert.True(t, o.available("a"))
+	o.record("auth", "a", 2, nil)
+	ejected, _, _ := o.state("a")
+	assert.False(t, ejected)
+	assert.True(t, o.available("a"))
+	assert.Equal(t, 0, o.states["a"].ejectionCount)
+}
+
+func TestOutlierForcedOut(t *testing.T) {
+	o := newOutlierDetector(testOutlierConfig)
+	o.setForcedOut("auth", "a", true)
+	assert.False(t, o.available("a"))
+	o.record("auth", "a", 2, nil)
+	assert.False(t, o.available("a"), "forced out node is not available even if call succeeds")
+
+	o.setForcedOut("auth", "a", false)
+	assert.True(t, o.available("a"))
+}
+
+func TestOutlierPrune(t *testing.T) {
+	o := newOutlierDetector(testOutlierConfig)
+	for i := 0; i < 3; i++ {
+		o.record("auth", "a", 2, errCallFailed)
+		o.record("outing", "c", 2, errCallFailed)
+	}
+	o.prune("auth", map[string]bool{"b": true})
+
+	assert.NotContains(t, o.states, "a")
+	assert.Contains(t, o.states, "c", "state of node in another service is kept")
+
+	// pruned node is not counted in max ejection percent any more
+	for i := 0; i < 3; i++ {
+		o.record("auth", "b", 2, errCallFailed)
+	}
+	assert.False(t, o.available("b"))
+}
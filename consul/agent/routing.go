@@ -0,0 +1,249 @@
+// add file in v.1.0.5
+// routing.go is file to declare method routing request to subset of service nodes with routing rule (ex, canary)
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"gateway/consul"
+	"github.com/hashicorp/consul/api"
+	"github.com/micro/go-micro/v2/client/selector"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/micro/go-micro/v2/registry"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// key of subset having nodes which are not target of any routing rule
+const defaultSubset = ""
+
+// name of rule returned when request is not matched with any routing rule
+const DefaultRouteName = "default"
+
+type nodeSubset struct {
+	nodes []*registry.Node
+	next  selector.Next
+}
+
+// get node in subset of routing rule matched with route, or default subset if not matched
+// move logic of GetNextServiceNode in v.1.0.2 to this method in v.1.0.5
+func (d *_default) GetNextServiceNodeWithRoute(service consul.ServiceName, route consul.Route) (*registry.Node, string, error) {
+	d.nodeMutex.RLock()
+	defer d.nodeMutex.RUnlock()
+
+	if !d.checkIfExistService(service) {
+		return nil, "", ErrUndefinedService
+	}
+
+	// change nodes in another goroutine, because nodes can't be changed with nodeMutex.RLock
+	if _, exist := d.nodes[service]; !exist {
+		go func() { _ = d.ChangeServiceNodes(service) }()
+		return nil, "", ErrUnavailableService
+	}
+
+	if len(d.nodes[service]) == 0 {
+		return nil, "", ErrAvailableNodeNotFound
+	}
+
+	for _, rule := range d.rules {
+		if consul.ServiceName(rule.Service) != service || !matchRoute(rule, route) {
+			continue
+		}
+		if node, err := d.selectInSubset(d.subsets[service][ruleTarget(rule)]); err == nil {
+			return node, rule.Name, nil
+		}
+	}
+
+	node, err := d.selectInSubset(d.subsets[service][defaultSubset])
+	return node, DefaultRouteName, err
+}
+
+// select node in subset, skipping node ejected by outlier detector
+func (d *_default) selectInSubset(subset *nodeSubset) (*registry.Node, error) {
+	if subset == nil || len(subset.nodes) == 0 {
+		return nil, ErrAvailableNodeNotFound
+	}
+
+	for range subset.nodes {
+		selectedNode, err := subset.next()
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to select node in selector, err: %v", err))
+		}
+		if d.outliers.available(selectedNode.Id) {
+			return selectedNode, nil
+		}
+	}
+
+	// strategy can select same node repeatedly, so look for available node in order
+	for _, node := range subset.nodes {
+		if d.outliers.available(node.Id) {
+			return node, nil
+		}
+	}
+
+	return nil, ErrAvailableNodeNotFound
+}
+
+// build subsets of service nodes per target of routing rule (call with nodeMutex.Lock)
+// nodes which are target of any rule are excluded from default subset unless all nodes are target
+func (d *_default) buildSubsets(service consul.ServiceName) {
+	var subsets = map[string]*nodeSubset{}
+	var targeted = map[string]bool{}
+	var strategy = d.strategyOf(service)
+
+	for _, rule := range d.rules {
+		if consul.ServiceName(rule.Service) != service {
+			continue
+		}
+		target := ruleTarget(rule)
+		if _, ok := subsets[target]; ok {
+			continue
+		}
+
+		var nodes []*registry.Node
+		for _, node := range d.nodes[service] {
+			if matchNode(rule, node) {
+				nodes = append(nodes, node)
+				targeted[node.Id] = true
+			}
+		}
+		subsets[target] = &nodeSubset{nodes: nodes, next: strategy([]*registry.Service{{Nodes: nodes}})}
+	}
+
+	var nodes []*registry.Node
+	for _, node := range d.nodes[service] {
+		if !targeted[node.Id] {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		nodes = d.nodes[service]
+	}
+	subsets[defaultSubset] = &nodeSubset{nodes: nodes, next: strategy([]*registry.Service{{Nodes: nodes}})}
+
+	d.subsets[service] = subsets
+}
+
+// watch routing rules in consul KV with blocking query & rebuild subsets of all service if changed
+func (d *_default) watchRoutingRules() {
+	var index uint64
+	var backoff = minWatchBackoff
+
+	for {
+		kv, meta, err := d.client.KV().Get(d.rulesKey, &api.QueryOptions{WaitIndex: index, WaitTime: d.watchWaitTime})
+		if err != nil {
+			log.Errorf("unable to watch routing rules, retry after %s, key: %s, err: %v", backoff.String(), d.rulesKey, err)
+			time.Sleep(backoff)
+			backoff = nextWatchBackoff(backoff)
+			continue
+		}
+		backoff = minWatchBackoff
+
+		switch {
+		case meta.LastIndex == index:
+			continue
+		case meta.LastIndex < index:
+			index = 0
+		default:
+			index = meta.LastIndex
+		}
+
+		var value []byte
+		if kv != nil {
+			value = kv.Value
+		}
+		if err = d.changeRoutingRules(value); err != nil {
+			log.Errorf("unable to change routing rules, rules are not changed, err: %v", err)
+			continue
+		}
+		log.Infof("routing rules changed by consul watch!! (index: %d)", index)
+	}
+}
+
+// change routing rules with KV value & rebuild subsets of all service, empty value means there is no rule
+func (d *_default) changeRoutingRules(value []byte) (err error) {
+	var conf consul.RoutingRulesKV
+	if len(value) != 0 {
+		if err = d.unmarshalKV(d.rulesKey, value, &conf); err != nil {
+			return
+		}
+	}
+
+	d.nodeMutex.Lock()
+	defer d.nodeMutex.Unlock()
+
+	d.rules = conf.Rules
+	for service := range d.nodes {
+		d.buildSubsets(service)
+	}
+	return
+}
+
+// return key of subset which is target of rule
+func ruleTarget(rule consul.RoutingRule) string {
+	return fmt.Sprintf("version=%s,tag=%s", rule.Version, rule.Tag)
+}
+
+// check if node is target of rule with version & tags in node metadata
+func matchNode(rule consul.RoutingRule, node *registry.Node) bool {
+	if rule.Version != "" && node.Metadata["Version"] != rule.Version {
+		return false
+	}
+	if rule.Tag != "" && !containString(strings.Split(node.Metadata["Tags"], ","), rule.Tag) {
+		return false
+	}
+	return true
+}
+
+// check if route matches all conditions of rule, rule without condition is never matched
+func matchRoute(rule consul.RoutingRule, route consul.Route) bool {
+	conditions := 0
+
+	if len(rule.Headers) != 0 {
+		conditions++
+		for key, value := range rule.Headers {
+			if route.Header.Get(key) != value {
+				return false
+			}
+		}
+	}
+
+	if len(rule.UserTypes) != 0 {
+		conditions++
+		if !containString(rule.UserTypes, route.UserType) {
+			return false
+		}
+	}
+
+	if rule.Percent != 0 {
+		conditions++
+		if routeBucket(rule, route) >= rule.Percent {
+			return false
+		}
+	}
+
+	return conditions != 0
+}
+
+// return bucket between 0 and 99 of route, same user is always in same bucket per rule
+func routeBucket(rule consul.RoutingRule, route consul.Route) int {
+	if route.UserUUID == "" {
+		return rand.Intn(100)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(rule.Name + route.UserUUID))
+	return int(h.Sum32() % 100)
+}
+
+func containString(arr []string, str string) bool {
+	for _, s := range arr {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
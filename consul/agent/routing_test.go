@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"fmt"
+	"gateway/consul"
+	"github.com/micro/go-micro/v2/client/selector"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestMatchRoute(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Canary", "true")
+
+	for _, c := range []struct {
+		name     string
+		rule     consul.RoutingRule
+		route    consul.Route
+		expected bool
+	}{
+		{"rule without condition", consul.RoutingRule{}, consul.Route{Header: header, UserType: "student"}, false},
+		{"matched header", consul.RoutingRule{Headers: map[string]string{"X-Canary": "true"}}, consul.Route{Header: header}, true},
+		{"unmatched header", consul.RoutingRule{Headers: map[string]string{"X-Canary": "false"}}, consul.Route{Header: header}, false},
+		{"header not exist", consul.RoutingRule{Headers: map[string]string{"X-Canary": "true"}}, consul.Route{Header: http.Header{}}, false},
+		{"matched user type", consul.RoutingRule{UserTypes: []string{"admin", "teacher"}}, consul.Route{UserType: "teacher"}, true},
+		{"unmatched user type", consul.RoutingRule{UserTypes: []string{"admin", "teacher"}}, consul.Route{UserType: "student"}, false},
+		{"every condition matched", consul.RoutingRule{Headers: map[string]string{"X-Canary": "true"}, UserTypes: []string{"student"}, Percent: 100},
+			consul.Route{Header: header, UserType: "student", UserUUID: "student-1"}, true},
+		{"one of conditions unmatched", consul.RoutingRule{Headers: map[string]string{"X-Canary": "true"}, UserTypes: []string{"admin"}},
+			consul.Route{Header: header, UserType: "student"}, false},
+	} {
+		assert.Equal(t, c.expected, matchRoute(c.rule, c.route), c.name)
+	}
+}
+
+func TestRouteBucket(t *testing.T) {
+	rule := consul.RoutingRule{Name: "canary", Percent: 30}
+
+	matched := 0
+	for i := 0; i < 1000; i++ {
+		route := consul.Route{UserUUID: fmt.Sprintf("student-%d", i)}
+		bucket := routeBucket(rule, route)
+		assert.True(t, bucket >= 0 && bucket < 100)
+		assert.Equal(t, bucket, routeBucket(rule, route), "same user must be in same bucket")
+		if matchRoute(rule, route) {
+			matched++
+		}
+	}
+	assert.InDelta(t, 300, matched, 60)
+
+	assert.False(t, matchRoute(consul.RoutingRule{Percent: 0, UserTypes: []string{"student"}}, consul.Route{UserType: "admin"}))
+	assert.True(t, matchRoute(consul.RoutingRule{Percent: 100}, consul.Route{}), "user without uuid is in random bucket")
+}
+
+func TestGetNextServiceNodeWithRoute(t *testing.T) {
+	m := Memory(Services([]consul.ServiceName{"auth"}), Strategy(selector.RoundRobin), RoutingRulesKey("routing"))
+	m.RegisterServiceNode("auth", &registry.Node{Id: "stable", Metadata: map[string]string{"Version": "1.0.4"}})
+	m.RegisterServiceNode("auth", &registry.Node{Id: "canary", Metadata: map[string]string{"Version": "1.0.5", "Tags": "canary,beta"}})
+
+	// every node is in default subset without rule
+	selected := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		node, name, err := m.GetNextServiceNodeWithRoute("auth", consul.Route{})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultRouteName, name)
+		selected[node.Id] = true
+	}
+	assert.Equal(t, map[string]bool{"stable": true, "canary": true}, selected)
+
+	rules := `{"rules": [{"name": "canary", "service": "auth", "tag": "canary", "user_types": ["admin"]}]}`
+	assert.NoError(t, m.PutKV("routing", []byte(rules)))
+	for i := 0; i < 4; i++ {
+		node, name, err := m.GetNextServiceNodeWithRoute("auth", consul.Route{UserType: "admin"})
+		assert.NoError(t, err)
+		assert.Equal(t, "canary", name)
+		assert.Equal(t, "canary", node.Id)
+
+		node, name, err = m.GetNextServiceNodeWithRoute("auth", consul.Route{UserType: "student"})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultRouteName, name)
+		assert.Equal(t, "stable", node.Id, "target of rule is excluded from default subset")
+	}
+
+	// request is routed to default subset if every node in target subset is ejected
+	assert.NoError(t, m.SetServiceNodeForcedOut("canary", true))
+	node, name, err := m.GetNextServiceNodeWithRoute("auth", consul.Route{UserType: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRouteName, name)
+	assert.Equal(t, "stable", node.Id)
+
+	_, _, err = m.GetNextServiceNodeWithRoute("outing", consul.Route{})
+	assert.Equal(t, ErrUndefinedService, err)
+}
@@ -0,0 +1,169 @@
+// add file in v.1.0.5
+// strategy.go is file to declare strategy selecting service node with call statistics of node
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"gateway/consul"
+	"github.com/micro/go-micro/v2/client/selector"
+	"github.com/micro/go-micro/v2/registry"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsStrategy is function returning selector.Strategy which can use call statistics of service node
+type StatsStrategy func(*NodeStats) selector.Strategy
+
+// default weight of node if weight is not set in consul service Weights & Meta
+const defaultNodeWeight = 1
+
+// FromSelector convert go-micro selector.Strategy not using statistics to StatsStrategy
+func FromSelector(s selector.Strategy) StatsStrategy {
+	return func(_ *NodeStats) selector.Strategy {
+		return s
+	}
+}
+
+// LeastOutstanding select node having the least outstanding request, select randomly among nodes having same count
+func LeastOutstanding(stats *NodeStats) selector.Strategy {
+	return func(services []*registry.Service) selector.Next {
+		nodes := flattenNodes(services)
+
+		return func() (*registry.Node, error) {
+			if len(nodes) == 0 {
+				return nil, selector.ErrNoneAvailable
+			}
+
+			var selected []*registry.Node
+			var least int64 = math.MaxInt64
+			for _, node := range nodes {
+				switch outstanding := stats.Outstanding(node.Id); {
+				case outstanding < least:
+					least = outstanding
+					selected = []*registry.Node{node}
+				case outstanding == least:
+					selected = append(selected, node)
+				}
+			}
+			return selected[rand.Intn(len(selected))], nil
+		}
+	}
+}
+
+// LatencyEWMA select node having the lowest latency EWMA weighted by outstanding request count
+// node never called is selected first to collect latency sample
+func LatencyEWMA(stats *NodeStats) selector.Strategy {
+	return func(services []*registry.Service) selector.Next {
+		nodes := flattenNodes(services)
+
+		return func() (*registry.Node, error) {
+			if len(nodes) == 0 {
+				return nil, selector.ErrNoneAvailable
+			}
+
+			var selected []*registry.Node
+			var lowest = math.MaxFloat64
+			for _, node := range nodes {
+				cost := float64(stats.Latency(node.Id)+time.Millisecond) * float64(stats.Outstanding(node.Id)+1)
+				switch {
+				case cost < lowest:
+					lowest = cost
+					selected = []*registry.Node{node}
+				case cost == lowest:
+					selected = append(selected, node)
+				}
+			}
+			return selected[rand.Intn(len(selected))], nil
+		}
+	}
+}
+
+// Weighted select node randomly in proportion to weight in node metadata set from consul service Weights & Meta
+func Weighted(_ *NodeStats) selector.Strategy {
+	return func(services []*registry.Service) selector.Next {
+		nodes := flattenNodes(services)
+
+		var weights = make([]int, len(nodes))
+		var total int
+		for i, node := range nodes {
+			weights[i] = nodeWeight(node)
+			total += weights[i]
+		}
+
+		return func() (*registry.Node, error) {
+			if len(nodes) == 0 || total == 0 {
+				return nil, selector.ErrNoneAvailable
+			}
+
+			r := rand.Intn(total)
+			for i, node := range nodes {
+				if r -= weights[i]; r < 0 {
+					return node, nil
+				}
+			}
+			return nodes[len(nodes)-1], nil
+		}
+	}
+}
+
+// StrategyFromName return StatsStrategy matched with name to use in configuration
+func StrategyFromName(name string) (StatsStrategy, error) {
+	switch name {
+	case "round-robin":
+		return FromSelector(selector.RoundRobin), nil
+	case "random":
+		return FromSelector(selector.Random), nil
+	case "least-outstanding":
+		return LeastOutstanding, nil
+	case "latency-ewma":
+		return LatencyEWMA, nil
+	case "weighted":
+		return Weighted, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("undefined load balancing strategy, name: %s", name))
+	}
+}
+
+// ServiceStrategiesFromString parse strategy name per service in configuration, ex) DMS.SMS.v1.service.auth=least-outstanding,...
+// blank string is parsed to empty map, so every service use default strategy
+func ServiceStrategiesFromString(s string) (strategies map[consul.ServiceName]StatsStrategy, err error) {
+	strategies = map[consul.ServiceName]StatsStrategy{}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		separated := strings.Split(pair, "=")
+		if len(separated) != 2 || separated[0] == "" {
+			err = errors.New(fmt.Sprintf("invalid format of service strategy, it must be <service>=<strategy>, pair: %s", pair))
+			return
+		}
+		strategy, nameErr := StrategyFromName(strings.TrimSpace(separated[1]))
+		if nameErr != nil {
+			err = nameErr
+			return
+		}
+		strategies[consul.ServiceName(strings.TrimSpace(separated[0]))] = strategy
+	}
+	return
+}
+
+// get weight of node from metadata, return default weight if not exist or invalid
+func nodeWeight(node *registry.Node) int {
+	weight, err := strconv.Atoi(node.Metadata["Weight"])
+	if err != nil || weight < 0 {
+		return defaultNodeWeight
+	}
+	return weight
+}
+
+func flattenNodes(services []*registry.Service) (nodes []*registry.Node) {
+	for _, service := range services {
+		nodes = append(nodes, service.Nodes...)
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"errors"
+	"gateway/consul"
+	"github.com/micro/go-micro/v2/client/selector"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func servicesWithNodes(ids ...string) []*registry.Service {
+	var nodes []*registry.Node
+	for _, id := range ids {
+		nodes = append(nodes, &registry.Node{Id: id, Metadata: map[string]string{}})
+	}
+	return []*registry.Service{{Nodes: nodes}}
+}
+
+// return count of selected node id per id in n selection
+func countSelected(t *testing.T, next selector.Next, n int) map[string]int {
+	selected := map[string]int{}
+	for i := 0; i < n; i++ {
+		node, err := next()
+		assert.NoError(t, err)
+		selected[node.Id]++
+	}
+	return selected
+}
+
+func TestLeastOutstanding(t *testing.T) {
+	stats := newNodeStats()
+	stats.start("a")
+	stats.start("a")
+	stats.start("b")
+
+	next := LeastOutstanding(stats)(servicesWithNodes("a", "b", "c"))
+	assert.Equal(t, map[string]int{"c": 10}, countSelected(t, next, 10))
+
+	// select randomly among nodes having least outstanding request
+	stats.start("c")
+	selected := countSelected(t, next, 100)
+	assert.Equal(t, 0, selected["a"])
+	assert.Equal(t, 100, selected["b"]+selected["c"])
+
+	_, err := LeastOutstanding(stats)(nil)()
+	assert.Equal(t, selector.ErrNoneAvailable, err)
+}
+
+func TestLatencyEWMA(t *testing.T) {
+	stats := newNodeStats()
+	stats.finish("a", time.Millisecond*100, nil)
+	stats.finish("b", time.Millisecond*10, nil)
+	next := LatencyEWMA(stats)(servicesWithNodes("a", "b"))
+	assert.Equal(t, map[string]int{"b": 10}, countSelected(t, next, 10))
+
+	// node never called is selected first
+	next = LatencyEWMA(stats)(servicesWithNodes("a", "b", "c"))
+	assert.Equal(t, map[string]int{"c": 10}, countSelected(t, next, 10))
+
+	// cost is weighted by outstanding request count, (10ms + 1ms) * 11 > (100ms + 1ms) * 1
+	for i := 0; i < 10; i++ {
+		stats.start("b")
+	}
+	next = LatencyEWMA(stats)(servicesWithNodes("a", "b"))
+	assert.Equal(t, map[string]int{"a": 10}, countSelected(t, next, 10))
+
+	_, err := LatencyEWMA(stats)(nil)()
+	assert.Equal(t, selector.ErrNoneAvailable, err)
+}
+
+func TestWeighted(t *testing.T) {
+	services := servicesWithNodes("a", "b", "c")
+	services[0].Nodes[0].Metadata["Weight"] = "3"
+	services[0].Nodes[1].Metadata["Weight"] = "0"
+	services[0].Nodes[2].Metadata["Weight"] = "invalid" // default weight
+
+	selected := countSelected(t, Weighted(nil)(services), 4000)
+	assert.Equal(t, 0, selected["b"])
+	assert.InDelta(t, 3000, selected["a"], 200)
+	assert.InDelta(t, 1000, selected["c"], 200)
+
+	services[0].Nodes[0].Metadata["Weight"] = "0"
+	services[0].Nodes[2].Metadata["Weight"] = "0"
+	_, err := Weighted(nil)(services)()
+	assert.Equal(t, selector.ErrNoneAvailable, err)
+}
+
+func TestNodeStatsFinish(t *testing.T) {
+	stats := newNodeStats()
+	assert.Equal(t, time.Duration(0), stats.Latency("a"))
+
+	// first sample is EWMA itself
+	stats.start("a")
+	assert.Equal(t, int64(1), stats.Outstanding("a"))
+	stats.finish("a", time.Millisecond*100, nil)
+	assert.Equal(t, int64(0), stats.Outstanding("a"))
+	assert.Equal(t, time.Millisecond*100, stats.Latency("a"))
+
+	// sample right after last update affect little to EWMA
+	stats.finish("a", time.Millisecond*200, nil)
+	assert.InDelta(t, float64(time.Millisecond*100), float64(stats.Latency("a")), float64(time.Millisecond))
+	assert.Equal(t, int64(0), stats.Outstanding("a"), "outstanding request count must not be negative")
+
+	// error is recorded with penalty latency
+	stats.finish("b", time.Millisecond, errors.New("error"))
+	assert.Equal(t, errorPenaltyLatency, stats.Latency("b"))
+}
+
+func TestNodeStatsPrune(t *testing.T) {
+	m := Memory(Services([]consul.ServiceName{"auth", "outing"}), Strategy(selector.RoundRobin))
+	m.RegisterServiceNode("auth", &registry.Node{Id: "a"})
+	m.RegisterServiceNode("auth", &registry.Node{Id: "b"})
+	m.RegisterServiceNode("outing", &registry.Node{Id: "c"})
+	for _, id := range []string{"a", "b", "c"} {
+		m.stats.finish(id, time.Millisecond*100, nil)
+	}
+
+	// stats of node leaving every service are pruned, and stats of nodes in other service are kept
+	m.DeregisterServiceNode("auth", "a")
+	assert.NotContains(t, m.stats.stats, "a")
+	assert.Contains(t, m.stats.stats, "b")
+	assert.Contains(t, m.stats.stats, "c")
+
+	// stats of node is kept while it is in any of services
+	m.RegisterServiceNode("outing", &registry.Node{Id: "b"})
+	m.DeregisterServiceNode("auth", "b")
+	assert.Contains(t, m.stats.stats, "b")
+	m.DeregisterServiceNode("outing", "b")
+	assert.NotContains(t, m.stats.stats, "b")
+}
+
+func TestStrategyFromName(t *testing.T) {
+	for _, name := range []string{"round-robin", "random", "least-outstanding", "latency-ewma", "weighted"} {
+		strategy, err := StrategyFromName(name)
+		assert.NoErrorf(t, err, "strategy name: %s", name)
+		assert.NotNilf(t, strategy, "strategy name: %s", name)
+	}
+	_, err := StrategyFromName("undefined")
+	assert.Error(t, err)
+}
+
+func TestServiceStrategiesFromString(t *testing.T) {
+	for _, c := range []struct {
+		s        string
+		services []consul.ServiceName
+		isErr    bool
+	}{
+		{"", nil, false},
+		{" , ", nil, false},
+		{"auth=least-outstanding", []consul.ServiceName{"auth"}, false},
+		{"auth = least-outstanding, outing=latency-ewma", []consul.ServiceName{"auth", "outing"}, false},
+		{"auth", nil, true},
+		{"=weighted", nil, true},
+		{"auth=least-outstanding=weighted", nil, true},
+		{"auth=undefined", nil, true},
+	} {
+		strategies, err := ServiceStrategiesFromString(c.s)
+		if c.isErr {
+			assert.Errorf(t, err, "string: %q", c.s)
+			continue
+		}
+		assert.NoErrorf(t, err, "string: %q", c.s)
+		assert.Lenf(t, strategies, len(c.services), "string: %q", c.s)
+		for _, service := range c.services {
+			assert.Containsf(t, strategies, service, "string: %q", c.s)
+		}
+	}
+}
@@ -7,5 +7,59 @@ package consul
 type RedisConfigKV struct {
 	Host string `json:"host" validate:"required"`
 	Port int    `json:"port" validate:"required"`
-	DB   int    `json:"DB" validate:"required"`
+	DB   int    `json:"DB" validate:"min=0"` // change in v.1.0.5, DB 0 (default DB of redis) is allowed
+}
+
+// entity about routing rules KV used for canary release (add in v.1.0.5)
+type RoutingRulesKV struct {
+	Rules []RoutingRule `json:"rules" validate:"dive"`
+}
+
+// rule routing request matched with conditions to service nodes having specific version or tag
+// all conditions set in rule have to be matched, and rule without any condition never be matched
+type RoutingRule struct {
+	Name    string `json:"name" validate:"required"`
+	Service string `json:"service" validate:"required"`
+
+	// target nodes of rule, version is from "version" in service Meta & tag is from service Tags
+	Version string `json:"version" validate:"required_without=Tag"`
+	Tag     string `json:"tag" validate:"required_without=Version"`
+
+	// conditions of rule
+	Percent   int               `json:"percent" validate:"min=0,max=100"`
+	Headers   map[string]string `json:"headers"`
+	UserTypes []string          `json:"user_types"`
+}
+
+// entity about outing policy KV, rules are evaluated in order when student creates outing (add in v.1.0.5)
+type OutingPolicyKV struct {
+	Rules []OutingPolicyRule `json:"rules" yaml:"rules" validate:"dive"`
+}
+
+// rule of school about outing, rule is applied to outing only if all conditions set in rule are matched
+// type of rule is one of time_window (deny outing overlapping time window), quota (limit number of outings in period)
+// and parent_approval (deny outing of student without parent who can approve it)
+type OutingPolicyRule struct {
+	ID          string `json:"id" yaml:"id" validate:"required"`
+	Type        string `json:"type" yaml:"type" validate:"required,oneof=time_window quota parent_approval"`
+	Description string `json:"description" yaml:"description"`
+
+	// conditions of rule, empty condition means all
+	Grades           []int    `json:"grades" yaml:"grades"`
+	Groups           []int    `json:"groups" yaml:"groups"`
+	Situations       []string `json:"situations" yaml:"situations"`
+	ExemptSituations []string `json:"exempt_situations" yaml:"exempt_situations"` // situation overriding rule, ex) emergency
+
+	// time window in school time zone, window passes midnight if From is after To, ex) 21:00 ~ 06:00
+	// outing is denied if it overlaps window starting on one of weekdays
+	Weekdays []string `json:"weekdays" yaml:"weekdays" validate:"dive,oneof=Sunday Monday Tuesday Wednesday Thursday Friday Saturday"`
+	From     string   `json:"from" yaml:"from" validate:"required_if=Type time_window,omitempty,datetime=15:04"`
+	To       string   `json:"to" yaml:"to" validate:"required_if=Type time_window,omitempty,datetime=15:04"`
+
+	// quota of outings per student in period, max outings is pointer so that 0 (deny every outing) can be set
+	MaxOutings *int   `json:"max_outings" yaml:"max_outings" validate:"required_if=Type quota,omitempty,min=0"`
+	Period     string `json:"period" yaml:"period" validate:"required_if=Type quota,omitempty,oneof=day week month"`
+
+	// days on which rule is applied, school_days (not day off) or days_off (public holiday & closure day), empty means every day
+	Days string `json:"days" yaml:"days" validate:"omitempty,oneof=school_days days_off"`
 }
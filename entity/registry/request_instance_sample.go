@@ -51,12 +51,26 @@ var requestSamples = map[string]interface{}{
 	"CreateOutingRequest": entity.CreateOutingRequest{},
 	"GetStudentOutingsRequest": entity.GetStudentOutingsRequest{},
 	"GetOutingWithFilterRequest": entity.GetOutingWithFilterRequest{},
+	"TakeActionInOutingsRequest": entity.TakeActionInOutingsRequest{}, // add in v.1.0.5
+	"ExportOutingsToExcelRequest": entity.ExportOutingsToExcelRequest{}, // add in v.1.0.5
+	"GetOutingCardQRCodeRequest": entity.GetOutingCardQRCodeRequest{}, // add in v.1.0.5
+	"VerifyOutingCardQRCodeRequest": entity.VerifyOutingCardQRCodeRequest{}, // add in v.1.0.5
+	"GetOutingStatisticsRequest": entity.GetOutingStatisticsRequest{}, // add in v.1.0.5
+	"SetOnDutyTeachersRequest": entity.SetOnDutyTeachersRequest{}, // add in v.1.0.5
+	"SetOutingScopeExceptionRequest": entity.SetOutingScopeExceptionRequest{}, // add in v.1.0.5
 
 	// in "entity/request_schedule.go"
 	"CreateScheduleRequest": entity.CreateScheduleRequest{},
 	"GetScheduleRequest": entity.GetScheduleRequest{},
 	"GetTimeTableRequest": entity.GetTimeTableRequest{},
+	"GetTimeTablesInRangeRequest": entity.GetTimeTablesInRangeRequest{}, // add in v.1.0.5
 	"UpdateScheduleRequest": entity.UpdateScheduleRequest{},
+	"ImportSchedulesRequest": entity.ImportSchedulesRequest{}, // add in v.1.0.5
+	"CreateScheduleSeriesRequest": entity.CreateScheduleSeriesRequest{}, // add in v.1.0.5
+	"UpdateScheduleOccurrencesRequest": entity.UpdateScheduleOccurrencesRequest{}, // add in v.1.0.5
+	"DeleteScheduleOccurrencesRequest": entity.DeleteScheduleOccurrencesRequest{}, // add in v.1.0.5
+	"GetDaysOffRequest": entity.GetDaysOffRequest{}, // add in v.1.0.5
+	"SetSchoolClosureRequest": entity.SetSchoolClosureRequest{}, // add in v.1.0.5
 
 	// in "entity/request_xlsx.go"
 	"AddUnsignedStudentsFromExcelRequest": entity.AddUnsignedStudentsFromExcelRequest{},
@@ -6,17 +6,17 @@ import (
 
 // request entity of POST /v1/outings
 type CreateOutingRequest struct {
-	StartTime int64  `json:"start_time" validate:"required,int_len=10"`
-	EndTime   int64  `json:"end_time" validate:"required,int_len=10"`
-	Place     string `json:"place" validate:"required,max=150"`
-	Reason    string `json:"reason" validate:"required,max=150"`
-	Situation string `json:"situation" validate:"required,values=normal&emergency"`
+	StartTime Timestamp `json:"start_time" validate:"required,int_len=10"` // change type in v.1.0.5
+	EndTime   Timestamp `json:"end_time" validate:"required,int_len=10"`   // change type in v.1.0.5
+	Place     string    `json:"place" validate:"required,max=150"`
+	Reason    string    `json:"reason" validate:"required,max=150"`
+	Situation string    `json:"situation" validate:"required,values=normal&emergency"`
 }
 
 func (from CreateOutingRequest) GenerateGRPCRequest() (to *outingproto.CreateOutingRequest) {
 	to = new(outingproto.CreateOutingRequest)
-	to.StartTime = from.StartTime
-	to.EndTime = from.EndTime
+	to.StartTime = from.StartTime.Unix()
+	to.EndTime = from.EndTime.Unix()
 	to.Place = from.Place
 	to.Reason = from.Reason
 	to.Situation = from.Situation
@@ -64,3 +64,74 @@ func (from GetOutingWithFilterRequest) GenerateGRPCRequest() (to *outingproto.Ge
 	to.Floor = from.Floor
 	return
 }
+
+// request entity of POST /v1/outings/actions/:action (add in v.1.0.5)
+type TakeActionInOutingsRequest struct {
+	OutingUUIDs []string `json:"outing_uuids" validate:"required,min=1,max=100,unique,dive,required,uuid=outing,len=19"`
+}
+
+// request entity of GET /v1/outings/exported-to/excel (add in v.1.0.5)
+// outings which start between StartDate & EndDate (inclusive, in school time zone) are exported
+type ExportOutingsToExcelRequest struct {
+	StartDate string `form:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `form:"end_date" validate:"required,datetime=2006-01-02"`
+	Status    string `form:"status"`
+	Grade     int32  `form:"grade" validate:"int_range=0~3"`
+	Group     int32  `form:"group" validate:"int_range=0~4"`
+	Floor     int32  `form:"floor" validate:"int_range=0~5"`
+}
+
+func (from ExportOutingsToExcelRequest) GenerateGRPCRequest(start, count int32) (to *outingproto.GetOutingWithFilterRequest) {
+	to = new(outingproto.GetOutingWithFilterRequest)
+	to.Start = start
+	to.Count = count
+	to.Status = from.Status
+	to.Grade = from.Grade
+	to.Group = from.Group
+	to.Floor = from.Floor
+	return
+}
+
+// request entity of GET /v1/outings/uuid/:outing_uuid/card/qr-code (add in v.1.0.5)
+type GetOutingCardQRCodeRequest struct {
+	Format string `form:"format" validate:"omitempty,values=png&svg"`
+	Scale  int    `form:"scale" validate:"int_range=0~20"`
+}
+
+// request entity of POST /v1/outings/card/verification (add in v.1.0.5)
+type VerifyOutingCardQRCodeRequest struct {
+	Payload string `json:"payload" validate:"required,max=300"`
+}
+
+// request entity of GET /v1/outings/statistics (add in v.1.0.5)
+// outings which start between StartDate & EndDate (inclusive, in school time zone) are counted
+type GetOutingStatisticsRequest struct {
+	StartDate string `form:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `form:"end_date" validate:"required,datetime=2006-01-02"`
+	Grade     int32  `form:"grade" validate:"int_range=0~3"`
+	Group     int32  `form:"group" validate:"int_range=0~4"`
+	Floor     int32  `form:"floor" validate:"int_range=0~5"`
+	Format    string `form:"format" validate:"omitempty,values=json&csv"`
+}
+
+// request entity of PUT /v1/outings/emergency/on-duty-teachers (add in v.1.0.5)
+// on-duty teachers are replaced with teachers in request, and are notified when emergency outing is created
+type SetOnDutyTeachersRequest struct {
+	TeacherUUIDs []string `json:"teacher_uuids" validate:"max=50,unique,dive,required,uuid=teacher,len=20"`
+}
+
+// request entity of PUT /v1/outings/scope-exceptions/teachers/:teacher_uuid (add in v.1.0.5)
+// scope "all" allows every outing (ex, dormitory supervisor), and "grade" allows every class in own grade
+type SetOutingScopeExceptionRequest struct {
+	Scope string `json:"scope" validate:"required,values=all&grade"`
+}
+
+func (from GetOutingStatisticsRequest) GenerateGRPCRequest(start, count int32) (to *outingproto.GetOutingWithFilterRequest) {
+	to = new(outingproto.GetOutingWithFilterRequest)
+	to.Start = start
+	to.Count = count
+	to.Grade = from.Grade
+	to.Group = from.Group
+	to.Floor = from.Floor
+	return
+}
@@ -2,19 +2,20 @@ package entity
 
 import (
 	scheduleproto "gateway/proto/golang/schedule"
+	"mime/multipart"
 )
 
 // request entity of POST /v1/schedules
 type CreateScheduleRequest struct {
-	StartDate int64  `json:"start_date" validate:"required,int_len=10"`
-	EndDate   int64  `json:"end_date" validate:"required,int_len=10"`
-	Detail    string `json:"detail" validate:"required,max=100"`
+	StartDate Timestamp `json:"start_date" validate:"required,int_len=10"` // change type in v.1.0.5
+	EndDate   Timestamp `json:"end_date" validate:"required,int_len=10"`   // change type in v.1.0.5
+	Detail    string    `json:"detail" validate:"required,max=100"`
 }
 
 func (from CreateScheduleRequest) GenerateGRPCRequest() (to *scheduleproto.CreateScheduleRequest) {
 	to = new(scheduleproto.CreateScheduleRequest)
-	to.StartDate = from.StartDate
-	to.EndDate = from.EndDate
+	to.StartDate = from.StartDate.Unix()
+	to.EndDate = from.EndDate.Unix()
 	to.Detail = from.Detail
 	return
 }
@@ -47,17 +48,78 @@ func (from GetTimeTableRequest) GenerateGRPCRequest() (to *scheduleproto.GetTime
 	return
 }
 
+// request entity of GET /v1/time-tables (add in v.1.0.5)
+// timetables of days from StartDate to EndDate (inclusive, in school time zone) are got, EndDate is 4 days after StartDate if not set
+type GetTimeTablesInRangeRequest struct {
+	StartDate string `form:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `form:"end_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
 // request entity of PATCH /v1/schedules/uuid/{schedule_uuid}
 type UpdateScheduleRequest struct {
-	StartDate int64  `json:"start_date" validate:"required,int_len=10"`
-	EndDate   int64  `json:"end_date" validate:"required,int_len=10"`
-	Detail    string `json:"detail" validate:"required,max=100"`
+	StartDate Timestamp `json:"start_date" validate:"required,int_len=10"` // change type in v.1.0.5
+	EndDate   Timestamp `json:"end_date" validate:"required,int_len=10"`   // change type in v.1.0.5
+	Detail    string    `json:"detail" validate:"required,max=100"`
 }
 
 func (from UpdateScheduleRequest) GenerateGRPCRequest() (to *scheduleproto.UpdateScheduleRequest) {
 	to = new(scheduleproto.UpdateScheduleRequest)
-	to.StartDate = from.StartDate
-	to.EndDate = from.EndDate
+	to.StartDate = from.StartDate.Unix()
+	to.EndDate = from.EndDate.Unix()
 	to.Detail = from.Detail
 	return
 }
+
+// request entity of POST /v1/schedules/imported-from/file (add in v.1.0.5)
+// file must be .ics or .xlsx, and only preview of import is responded if dry_run is true
+type ImportSchedulesRequest struct {
+	File   *multipart.FileHeader `form:"file" json:"-" validate:"required"`
+	DryRun bool                  `form:"dry_run" json:"dry_run"`
+}
+
+// RecurrenceRule is RRULE-style rule of recurring schedule (add in v.1.0.5)
+// occurrence repeats every Interval weeks (on ByDay, or weekday of start date) or every Interval months (on day of start date)
+// until Until or Count occurrences, and occurrence of which start date is in Exceptions (EXDATE) is excluded
+type RecurrenceRule struct {
+	Freq       string    `json:"freq" validate:"required,values=weekly&monthly"`
+	Interval   int       `json:"interval,omitempty" validate:"omitempty,min=1,max=12"`
+	ByDay      []string  `json:"by_day,omitempty" validate:"omitempty,max=7,dive,values=MO&TU&WE&TH&FR&SA&SU"`
+	Until      Timestamp `json:"until,omitempty" validate:"required_without=Count,omitempty,int_len=10"`
+	Count      int       `json:"count,omitempty" validate:"required_without=Until,omitempty,min=1,max=100"`
+	Exceptions []string  `json:"exceptions,omitempty" validate:"omitempty,dive,datetime=2006-01-02"`
+}
+
+// request entity of POST /v1/schedules/series (add in v.1.0.5)
+// StartDate & EndDate are dates of first occurrence, and they are repeated with Recurrence
+type CreateScheduleSeriesRequest struct {
+	StartDate  Timestamp      `json:"start_date" validate:"required,int_len=10"`
+	EndDate    Timestamp      `json:"end_date" validate:"required,int_len=10"`
+	Detail     string         `json:"detail" validate:"required,max=100"`
+	Recurrence RecurrenceRule `json:"recurrence"`
+}
+
+// request entity of PATCH /v1/schedules/series/{series_uuid}/occurrences/{date} (add in v.1.0.5)
+// StartDate & EndDate are new dates of occurrence in uri, and occurrences in scope are shifted as much as it
+type UpdateScheduleOccurrencesRequest struct {
+	Scope     string    `json:"scope" validate:"required,values=this&following&all"`
+	StartDate Timestamp `json:"start_date" validate:"required,int_len=10"`
+	EndDate   Timestamp `json:"end_date" validate:"required,int_len=10"`
+	Detail    string    `json:"detail" validate:"required,max=100"`
+}
+
+// request entity of DELETE /v1/schedules/series/{series_uuid}/occurrences/{date} (add in v.1.0.5)
+type DeleteScheduleOccurrencesRequest struct {
+	Scope string `form:"scope" validate:"required,values=this&following&all"`
+}
+
+// request entity of GET /v1/calendar/days-off (add in v.1.0.5)
+// public holidays & closure days from StartDate to EndDate (inclusive, in school time zone) are got
+type GetDaysOffRequest struct {
+	StartDate string `form:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `form:"end_date" validate:"required,datetime=2006-01-02"`
+}
+
+// request entity of PUT /v1/calendar/closures/{date} (add in v.1.0.5)
+type SetSchoolClosureRequest struct {
+	Reason string `json:"reason" validate:"required,max=100"`
+}
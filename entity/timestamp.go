@@ -0,0 +1,50 @@
+// add file in v.1.0.5
+// timestamp.go is file that declare type of timestamp field in request entity
+// timestamp can be sent as epoch seconds or ISO-8601 string with offset, ex) 1602982800, "2020-10-18T10:00:00+09:00"
+
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Timestamp is unix time in seconds, unmarshaled from epoch number, string of epoch or ISO-8601 string with offset
+// it is int64 in kind, so validator for integer such as int_len can be used in it
+type Timestamp int64
+
+func (t *Timestamp) UnmarshalJSON(b []byte) (err error) {
+	if len(b) == 0 || b[0] != '"' {
+		var unix int64
+		if err = json.Unmarshal(b, &unix); err != nil {
+			err = errors.New(fmt.Sprintf("timestamp must be epoch seconds or ISO-8601 string with offset, err: %v", err))
+			return
+		}
+		*t = Timestamp(unix)
+		return
+	}
+
+	var value string
+	if err = json.Unmarshal(b, &value); err != nil {
+		return
+	}
+	if unix, parseErr := strconv.ParseInt(value, 10, 64); parseErr == nil {
+		*t = Timestamp(unix)
+		return
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("timestamp must be epoch seconds or ISO-8601 string with offset, value: %s", value))
+		return
+	}
+	*t = Timestamp(parsed.Unix())
+	return
+}
+
+// return timestamp as unix time in seconds
+func (t Timestamp) Unix() int64 {
+	return int64(t)
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTimestampUnmarshalJSON(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		data     string
+		expected Timestamp
+		isErr    bool
+	}{
+		{"epoch seconds", `1602982800`, 1602982800, false},
+		{"string of epoch seconds", `"1602982800"`, 1602982800, false},
+		{"ISO-8601 with offset", `"2020-10-18T10:00:00+09:00"`, 1602982800, false},
+		{"ISO-8601 in UTC", `"2020-10-18T01:00:00Z"`, 1602982800, false},
+		{"ISO-8601 with fraction of second", `"2020-10-18T10:00:00.5+09:00"`, 1602982800, false},
+		{"null", `null`, 0, false},
+		{"ISO-8601 without offset", `"2020-10-18T10:00:00"`, 0, true},
+		{"date only", `"2020-10-18"`, 0, true},
+		{"empty string", `""`, 0, true},
+		{"fraction of epoch seconds", `1602982800.5`, 0, true},
+		{"boolean", `true`, 0, true},
+	} {
+		var timestamp Timestamp
+		err := json.Unmarshal([]byte(c.data), &timestamp)
+		if c.isErr {
+			assert.Error(t, err, c.name)
+			continue
+		}
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.expected, timestamp, c.name)
+	}
+
+	// timestamp field in request entity
+	var request struct {
+		StartTime Timestamp `json:"start_time"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"start_time": "2020-10-18T10:00:00+09:00"}`), &request))
+	assert.Equal(t, int64(1602982800), request.StartTime.Unix())
+}
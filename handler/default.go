@@ -2,16 +2,15 @@ package handler
 
 import (
 	"gateway/consul"
-	"gateway/entity"
 	announcementproto "gateway/proto/golang/announcement"
 	authproto "gateway/proto/golang/auth"
 	clubproto "gateway/proto/golang/club"
 	outingproto "gateway/proto/golang/outing"
 	scheduleproto "gateway/proto/golang/schedule"
 	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/eapache/go-resiliency/breaker"
 	"github.com/go-playground/validator/v10"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/micro/go-micro/v2/client"
 	"github.com/opentracing/opentracing-go"
 	"github.com/sirupsen/logrus"
@@ -20,9 +19,6 @@ import (
 	"time"
 )
 
-type serviceName string
-type consulIndex string
-
 type _default struct {
 	authService authproto.AuthServiceClient
 	clubService clubproto.ClubServiceClient
@@ -34,21 +30,67 @@ type _default struct {
 	logger          *logrus.Logger
 	tracer          opentracing.Tracer
 	validate        *validator.Validate
-	breakers        map[string]*breaker.Breaker
+	breakers        map[string]*nodeBreaker // change from *breaker.Breaker in v.1.0.5
 	mutex           sync.Mutex
 	BreakerCfg      BreakerConfig
 	DefaultCallOpts []client.CallOption
 	client          *http.Client
 	location        *time.Location
 
-	// filtering consul watch index per service (Add in v.1.0.2)
-	consulIndexFilter map[serviceName]map[consulIndex][]entity.PublishConsulChangeEventRequest
-
 	// aws session for publish message in sns (Add in v.1.0.2)
 	awsSession *session.Session
 
 	// redis client for cashing responses of services (Add in v.1.0.3)
 	redisClient *redis.Client
+
+	// bulkheads limiting in-flight requests per service or method (add in v.1.0.5)
+	bulkheads     map[string]*bulkhead
+	bulkheadMutex sync.Mutex
+	BulkheadCfg   BulkheadConfig            // default config of bulkhead per service
+	BulkheadCfgs  map[string]BulkheadConfig // config per service or method, key is "{service}" or "{service}/{method}"
+
+	// streams of outing event connected to this gateway & redis topic to publish outing event (add in v.1.0.5)
+	streams          map[*outingStream]struct{}
+	streamMutex      sync.RWMutex
+	outingEventTopic string
+
+	// secret key to sign QR code of outing card & outing status certifiable with it (add in v.1.0.5)
+	outingCardSecret          []byte
+	certifiableOutingStatuses map[string]bool
+
+	// TTL policy of outing statistics cached in redis (add in v.1.0.5)
+	StatisticsTTL StatisticsTTLConfig
+
+	// notifier & config of overdue outing detector, replica id is used to elect leader of job (add in v.1.0.5)
+	notifier   Notifier
+	OverdueCfg OverdueConfig
+	replicaID  string
+
+	// secret key to sign parent approval token, uuid of admin resolving parent of outing & TTL of token (add in v.1.0.5)
+	approvalSecret     []byte
+	approvalWorkerUUID string
+	ApprovalTokenTTL   time.Duration
+
+	// source of outing policy & rules in use, rules are reloaded from source in interval (add in v.1.0.5)
+	policySource         OutingPolicySource
+	PolicyReloadInterval time.Duration
+	outingPolicy         consul.OutingPolicyKV
+	policyMutex          sync.RWMutex
+
+	// config of escalation workflow of emergency outing (add in v.1.0.5)
+	EscalationCfg EscalationConfig
+
+	// TTL of class of teacher cached in redis, used to narrow scope of outing filter (add in v.1.0.5)
+	TeacherClassTTL time.Duration
+
+	// config of iCalendar feed of schedules (add in v.1.0.5)
+	FeedCfg ScheduleFeedConfig
+
+	// path of holiday file & public holidays in use, holidays are reloaded from file in interval (add in v.1.0.5)
+	holidayFile           string
+	HolidayReloadInterval time.Duration
+	holidays              map[string]string
+	holidayMutex          sync.RWMutex
 }
 
 type BreakerConfig struct {
@@ -59,6 +101,11 @@ type BreakerConfig struct {
 
 func Default(setters ...FieldSetter) (h *_default) {
 	h = new(_default)
+	h.BulkheadCfgs = map[string]BulkheadConfig{}
+	h.StatisticsTTL = StatisticsTTLConfig{
+		Current: time.Minute,
+		Past:    time.Hour * 24,
+	}
 	for _, setter := range setters {
 		setter(h)
 	}
@@ -70,9 +117,48 @@ func Default(setters ...FieldSetter) (h *_default) {
 	}
 	h.DefaultCallOpts = []client.CallOption{client.WithDialTimeout(time.Second * 2), client.WithRequestTimeout(time.Second * 3)}
 	h.mutex = sync.Mutex{}
-	h.breakers = map[string]*breaker.Breaker{}
+	h.breakers = map[string]*nodeBreaker{}
 	h.client = &http.Client{}
-	h.consulIndexFilter = map[serviceName]map[consulIndex][]entity.PublishConsulChangeEventRequest{}
+	h.BulkheadCfg = BulkheadConfig{
+		MaxConcurrent: 100,
+		MaxQueue:      50,
+		QueueTimeout:  time.Millisecond * 500,
+	}
+	h.bulkheadMutex = sync.Mutex{}
+	h.bulkheads = map[string]*bulkhead{}
+	h.streamMutex = sync.RWMutex{}
+	h.streams = map[*outingStream]struct{}{}
+	if h.notifier == nil {
+		h.notifier = LogNotifier()
+	}
+	if h.OverdueCfg.Interval == 0 {
+		h.OverdueCfg.Interval = time.Minute
+	}
+	h.replicaID = uuid.New().String()
+	h.policyMutex = sync.RWMutex{}
+	h.holidayMutex = sync.RWMutex{}
+	h.holidays = map[string]string{}
+	if h.EscalationCfg.Timeout == 0 {
+		h.EscalationCfg.Timeout = time.Minute * 10
+	}
+	if h.EscalationCfg.Interval == 0 {
+		h.EscalationCfg.Interval = time.Second * 30
+	}
+	if h.TeacherClassTTL == 0 {
+		h.TeacherClassTTL = time.Minute * 10
+	}
+	if h.location == nil {
+		h.location = time.FixedZone("KST", 9*60*60) // school is in Korea if time zone of school is not set
+	}
+	if h.ApprovalTokenTTL == 0 {
+		h.ApprovalTokenTTL = time.Hour * 24
+	}
+	if h.FeedCfg.FutureMonths == 0 {
+		h.FeedCfg.FutureMonths = 6
+	}
+	if h.FeedCfg.CacheTTL == 0 {
+		h.FeedCfg.CacheTTL = time.Hour
+	}
 
 	return
 }
@@ -133,6 +219,7 @@ func Validate(validate *validator.Validate) FieldSetter {
 	}
 }
 
+// set time zone of school, used to compute date boundaries & format local time (comment added in v.1.0.5)
 func Location(location *time.Location) FieldSetter {
 	return func(h *_default) {
 		h.location = location
@@ -150,3 +237,103 @@ func RedisClient(r *redis.Client) FieldSetter {
 		h.redisClient = r
 	}
 }
+
+// set config of bulkhead per service or method, key is "{service}" or "{service}/{method}" (add in v.1.0.5)
+func BulkheadConfigs(configs map[string]BulkheadConfig) FieldSetter {
+	return func(h *_default) {
+		for key, config := range configs {
+			h.BulkheadCfgs[key] = config
+		}
+	}
+}
+
+// set redis topic to publish outing event, outing event isn't published if topic is empty (add in v.1.0.5)
+func OutingEventTopic(topic string) FieldSetter {
+	return func(h *_default) {
+		h.outingEventTopic = topic
+	}
+}
+
+// set secret key to sign & verify QR code of outing card (add in v.1.0.5)
+func OutingCardSecret(secret string) FieldSetter {
+	return func(h *_default) {
+		h.outingCardSecret = []byte(secret)
+	}
+}
+
+// set outing status in which outing card can be verified, no outing can be verified if empty (add in v.1.0.5)
+func CertifiableOutingStatuses(statuses []string) FieldSetter {
+	return func(h *_default) {
+		h.certifiableOutingStatuses = map[string]bool{}
+		for _, status := range statuses {
+			h.certifiableOutingStatuses[status] = true
+		}
+	}
+}
+
+// set TTL policy of outing statistics cached in redis (add in v.1.0.5)
+func OutingStatisticsTTL(ttl StatisticsTTLConfig) FieldSetter {
+	return func(h *_default) {
+		h.StatisticsTTL = ttl
+	}
+}
+
+// set notifier sending notification to teacher, parent, etc (add in v.1.0.5)
+func UserNotifier(notifier Notifier) FieldSetter {
+	return func(h *_default) {
+		h.notifier = notifier
+	}
+}
+
+// set config of overdue outing detector, detector doesn't run if worker uuid is empty (add in v.1.0.5)
+func OverdueDetector(config OverdueConfig) FieldSetter {
+	return func(h *_default) {
+		h.OverdueCfg = config
+	}
+}
+
+// set secret key to sign parent approval token, uuid of admin used to resolve parent of outing & TTL of token (add in v.1.0.5)
+func ParentApprovalToken(secret, workerUUID string, ttl time.Duration) FieldSetter {
+	return func(h *_default) {
+		h.approvalSecret = []byte(secret)
+		h.approvalWorkerUUID = workerUUID
+		h.ApprovalTokenTTL = ttl
+	}
+}
+
+// set source of outing policy evaluated when student creates outing & interval to reload it (add in v.1.0.5)
+func OutingPolicy(source OutingPolicySource, reloadInterval time.Duration) FieldSetter {
+	return func(h *_default) {
+		h.policySource = source
+		h.PolicyReloadInterval = reloadInterval
+	}
+}
+
+// set config of escalation workflow of emergency outing, workflow doesn't run if worker uuid is empty (add in v.1.0.5)
+func EmergencyEscalation(config EscalationConfig) FieldSetter {
+	return func(h *_default) {
+		h.EscalationCfg = config
+	}
+}
+
+// set TTL of class of teacher cached in redis to narrow scope of outing filter (add in v.1.0.5)
+func TeacherClassCacheTTL(ttl time.Duration) FieldSetter {
+	return func(h *_default) {
+		h.TeacherClassTTL = ttl
+	}
+}
+
+// set range of months aggregated in iCalendar feed of schedules & TTL of cached feed (add in v.1.0.5)
+func ScheduleFeed(config ScheduleFeedConfig) FieldSetter {
+	return func(h *_default) {
+		h.FeedCfg = config
+	}
+}
+
+// set path of holiday file & interval to reload it, holiday calendar is disabled if path is empty (add in v.1.0.5)
+func HolidayCalendarFile(path string, reloadInterval time.Duration) FieldSetter {
+	return func(h *_default) {
+		h.holidayFile = path
+		h.HolidayReloadInterval = reloadInterval
+	}
+}
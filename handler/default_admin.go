@@ -0,0 +1,208 @@
+// add file in v.1.0.5
+// default_admin.go is file that declare handler of admin API to inspect & change state of gateway in outage
+
+package handler
+
+import (
+	"expvar"
+	"fmt"
+	consulagent "gateway/consul/agent"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// max number of redis keys returned or deleted at once in admin API
+const maxAdminCacheKeys = 1000
+
+var redisPatternReplacer = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// list service nodes discovered in consul agent with selector stats & ejection state
+func (h *_default) GetServiceNodesForAdmin(c *gin.Context) {
+	status, _code := http.StatusOK, 0
+	msg := "succeed to get state of service nodes"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "services": h.consulAgent.GetServiceNodeStates()})
+}
+
+// show state of circuit breaker per service node
+func (h *_default) GetBreakersForAdmin(c *gin.Context) {
+	h.mutex.Lock()
+	var breakers = map[string]breakerState{}
+	for nodeID, b := range h.breakers {
+		breakers[nodeID] = b.state()
+	}
+	h.mutex.Unlock()
+
+	status, _code := http.StatusOK, 0
+	msg := "succeed to get state of circuit breakers"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "breakers": breakers})
+}
+
+// show limits & in-flight, queued, completed, rejected count of bulkhead per service or method (add in v.1.0.5)
+func (h *_default) GetBulkheadsForAdmin(c *gin.Context) {
+	status, _code := http.StatusOK, 0
+	msg := "succeed to get state of bulkheads"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "bulkheads": h.bulkheadStates()})
+}
+
+// export variables published in expvar as JSON, such as memstats & state of bulkheads, to be scraped as metrics
+func (h *_default) GetDebugVarsForAdmin(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+// force service node out of rotation, or put it back in rotation with action in uri (force-out, restore)
+func (h *_default) TakeActionInServiceNodeForAdmin(c *gin.Context) {
+	var forcedOut bool
+	switch c.Param("action") {
+	case "force-out":
+		forcedOut = true
+	case "restore":
+		forcedOut = false
+	default:
+		status, _code := http.StatusNotFound, 0
+		msg := fmt.Sprintf("undefined action in service node, action: %s", c.Param("action"))
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	}
+
+	switch err := h.consulAgent.SetServiceNodeForcedOut(c.Param("node_id"), forcedOut); err {
+	case nil:
+		status, _code := http.StatusOK, 0
+		msg := fmt.Sprintf("succeed to take %s action in service node", c.Param("action"))
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	case consulagent.ErrUndefinedNode:
+		status, _code := http.StatusNotFound, 0
+		msg := fmt.Sprintf("service node with id is not exist, node id: %s", c.Param("node_id"))
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	default:
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to take action in service node, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	}
+}
+
+// reset circuit breaker of service node by replacing it with new breaker
+func (h *_default) ResetBreakerForAdmin(c *gin.Context) {
+	nodeID := c.Param("node_id")
+
+	h.mutex.Lock()
+	_, ok := h.breakers[nodeID]
+	if ok {
+		h.breakers[nodeID] = newNodeBreaker(h.BreakerCfg)
+	}
+	h.mutex.Unlock()
+
+	if !ok {
+		status, _code := http.StatusNotFound, 0
+		msg := fmt.Sprintf("circuit breaker of node is not exist, node id: %s", nodeID)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := "succeed to reset circuit breaker of node"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+}
+
+// change all service nodes in consul agent immediately
+func (h *_default) ChangeAllServiceNodesForAdmin(c *gin.Context) {
+	if err := h.consulAgent.ChangeAllServiceNodes(); err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to change all service nodes, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := "succeed to change all service nodes"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+}
+
+// list redis cache keys starting with prefix in query string
+func (h *_default) GetCacheKeysForAdmin(c *gin.Context) {
+	keys, err := h.scanRedisKeysWithPrefix(c.Query("prefix"))
+	if err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to scan redis keys, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := "succeed to get redis cache keys with prefix"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "keys": keys})
+}
+
+// fetch value & ttl of redis cache key
+func (h *_default) GetCacheForAdmin(c *gin.Context) {
+	key := c.Param("key")
+
+	value, err := h.redisClient.Get(ctx, key).Result()
+	switch err {
+	case nil:
+		break
+	case redis.Nil:
+		status, _code := http.StatusNotFound, 0
+		msg := fmt.Sprintf("redis cache key is not exist, key: %s", key)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	default:
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to get redis cache, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	}
+
+	ttl, _ := h.redisClient.TTL(ctx, key).Result()
+	status, _code := http.StatusOK, 0
+	msg := "succeed to get redis cache"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "key": key, "value": value, "ttl": ttl.String()})
+}
+
+// purge redis cache keys starting with prefix in query string, prefix must not be empty
+func (h *_default) DeleteCachesForAdmin(c *gin.Context) {
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		status, _code := http.StatusBadRequest, 0
+		msg := "prefix in query string must not be empty to purge caches"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	}
+
+	keys, err := h.scanRedisKeysWithPrefix(prefix)
+	if err == nil && len(keys) != 0 {
+		_, err = h.redisClient.Del(ctx, keys...).Result()
+	}
+	if err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to purge redis caches, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to purge redis caches with prefix, deleted key num: %d", len(keys))
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "keys": keys})
+}
+
+// scan redis keys starting with prefix using SCAN cmd instead of KEYS cmd, not to block redis server
+func (h *_default) scanRedisKeysWithPrefix(prefix string) (keys []string, err error) {
+	keys = []string{}
+	iter := h.redisClient.Scan(ctx, 0, escapeRedisPattern(prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) && len(keys) < maxAdminCacheKeys {
+		keys = append(keys, iter.Val())
+	}
+	if err = iter.Err(); err != nil {
+		return
+	}
+
+	sort.Strings(keys)
+	return
+}
+
+// escape special characters of glob-style pattern in redis
+func escapeRedisPattern(str string) string {
+	return redisPatternReplacer.Replace(str)
+}
@@ -19,7 +19,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/uber/jaeger-client-go"
 	"net/http"
-	"time"
 )
 
 func (h *_default) CreateAnnouncement(c *gin.Context) {
@@ -43,7 +42,7 @@ func (h *_default) CreateAnnouncement(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.CreateAnnouncementRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -54,12 +53,12 @@ func (h *_default) CreateAnnouncement(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.DefaultAnnouncementResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "CreateAnnouncement", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("CreateAnnouncement", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -67,7 +66,9 @@ func (h *_default) CreateAnnouncement(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.Uuid = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.CreateAnnouncement(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -92,11 +93,12 @@ func (h *_default) CreateAnnouncement(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to CreateAnnouncement service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("CreateAnnouncement returns unexpected type of error, err: %s", rpcErr.Error())
@@ -146,7 +148,7 @@ func (h *_default) GetAnnouncements(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetAnnouncementsRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -157,12 +159,12 @@ func (h *_default) GetAnnouncements(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.GetAnnouncementsResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "GetAnnouncements", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("GetAnnouncements", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -171,7 +173,9 @@ func (h *_default) GetAnnouncements(c *gin.Context) {
 		rpcReq.Uuid = uuidClaims.UUID
 		rpcReq.Type = c.Param("type")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.GetAnnouncements(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -196,11 +200,12 @@ func (h *_default) GetAnnouncements(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetAnnouncements service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetAnnouncements returns unexpected type of error, err: %s", rpcErr.Error())
@@ -257,7 +262,7 @@ func (h *_default) GetAnnouncementDetail(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -268,12 +273,12 @@ func (h *_default) GetAnnouncementDetail(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.GetAnnouncementDetailResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "GetAnnouncementDetail", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("GetAnnouncementDetail", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -282,7 +287,9 @@ func (h *_default) GetAnnouncementDetail(c *gin.Context) {
 		rpcReq.Uuid = uuidClaims.UUID
 		rpcReq.AnnouncementId = c.Param("announcement_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.GetAnnouncementDetail(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -307,11 +314,12 @@ func (h *_default) GetAnnouncementDetail(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetAnnouncementDetail service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetAnnouncementDetail returns unexpected type of error, err: %s", rpcErr.Error())
@@ -364,7 +372,7 @@ func (h *_default) UpdateAnnouncement(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.UpdateAnnouncementRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -375,12 +383,12 @@ func (h *_default) UpdateAnnouncement(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.DefaultAnnouncementResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "UpdateAnnouncement", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("UpdateAnnouncement", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -389,7 +397,9 @@ func (h *_default) UpdateAnnouncement(c *gin.Context) {
 		rpcReq.Uuid = uuidClaims.UUID
 		rpcReq.AnnouncementId = c.Param("announcement_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.UpdateAnnouncement(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -414,11 +424,12 @@ func (h *_default) UpdateAnnouncement(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to UpdateAnnouncement service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("UpdateAnnouncement returns unexpected type of error, err: %s", rpcErr.Error())
@@ -463,7 +474,7 @@ func (h *_default) DeleteAnnouncement(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -474,12 +485,12 @@ func (h *_default) DeleteAnnouncement(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.DefaultAnnouncementResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "DeleteAnnouncement", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("DeleteAnnouncement", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -488,7 +499,9 @@ func (h *_default) DeleteAnnouncement(c *gin.Context) {
 		rpcReq.Uuid = uuidClaims.UUID
 		rpcReq.AnnouncementId = c.Param("announcement_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.DeleteAnnouncement(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -513,11 +526,12 @@ func (h *_default) DeleteAnnouncement(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to DeleteAnnouncement service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("DeleteAnnouncement returns unexpected type of error, err: %s", rpcErr.Error())
@@ -562,7 +576,7 @@ func (h *_default) CheckAnnouncement(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -573,12 +587,12 @@ func (h *_default) CheckAnnouncement(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.CheckAnnouncementResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "CheckAnnouncement", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("CheckAnnouncement", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -586,7 +600,9 @@ func (h *_default) CheckAnnouncement(c *gin.Context) {
 		rpcReq := new(announcementproto.CheckAnnouncementRequest)
 		rpcReq.Uuid = c.Param("student_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.CheckAnnouncement(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -611,11 +627,12 @@ func (h *_default) CheckAnnouncement(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to CheckAnnouncement service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("CheckAnnouncement returns unexpected type of error, err: %s", rpcErr.Error())
@@ -665,7 +682,7 @@ func (h *_default) SearchAnnouncements(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.SearchAnnouncementsRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -676,12 +693,12 @@ func (h *_default) SearchAnnouncements(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.GetAnnouncementsResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "SearchAnnouncements", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("SearchAnnouncements", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -691,7 +708,9 @@ func (h *_default) SearchAnnouncements(c *gin.Context) {
 		rpcReq.Type = c.Param("type")
 		rpcReq.Query = c.Param("search_query")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.SearchAnnouncements(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -716,11 +735,12 @@ func (h *_default) SearchAnnouncements(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to SearchAnnouncements service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("SearchAnnouncements returns unexpected type of error, err: %s", rpcErr.Error())
@@ -782,7 +802,7 @@ func (h *_default) GetMyAnnouncements(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetMyAnnouncementsRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AnnouncementServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AnnouncementServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -793,12 +813,12 @@ func (h *_default) GetMyAnnouncements(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *announcementproto.GetAnnouncementsResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AnnouncementServiceName, "GetMyAnnouncements", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		announcementSrvSpan := h.tracer.StartSpan("GetMyAnnouncements", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -806,7 +826,9 @@ func (h *_default) GetMyAnnouncements(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.Uuid = c.Param("writer_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AnnouncementServiceName, selectedNode)
 		rpcResp, rpcErr = h.announcementService.GetMyAnnouncements(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		announcementSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		announcementSrvSpan.Finish()
 		return
@@ -831,11 +853,12 @@ func (h *_default) GetMyAnnouncements(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetMyAnnouncements service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetMyAnnouncements returns unexpected type of error, err: %s", rpcErr.Error())
@@ -0,0 +1,245 @@
+// add file in v.1.0.5
+// default_announcement_read.go is file that declare read receipts of announcement per student
+// read receipt is recorded when student get announcement detail, and writer can get read & unread students in target audience
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	announcementproto "gateway/proto/golang/announcement"
+	authproto "gateway/proto/golang/auth"
+	jwtutil "gateway/tool/jwt"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// number of announcements got per page & max number of pages while checking if announcement is written by user
+const (
+	myAnnouncementsPageSize = 50
+	maxMyAnnouncementsPages = 20
+)
+
+// max number of GetStudentUUIDsWithInform called at the same time while resolving target audience
+const maxConcurrentAudienceQueries = 4
+
+var (
+	errAnnouncementNotWritten = errors.New("announcement is not written by user")
+	errTooManyMyAnnouncements = errors.New(fmt.Sprintf("writer of announcement can't be checked, because user wrote more than %d announcements",
+		myAnnouncementsPageSize*maxMyAnnouncementsPages))
+)
+
+// sorted set of uuid of students who read announcement, score is unix time when student read it first
+func announcementReadsKey(announcementUUID string) string {
+	return fmt.Sprintf("announcement-reads.%s", announcementUUID)
+}
+
+// RecordAnnouncementRead is handler recording read receipt of student after announcement detail is got successfully
+// it must be registered in front of redis responder, so that read receipt is recorded even if response is cached
+func (h *_default) RecordAnnouncementRead(c *gin.Context) {
+	c.Next()
+
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	if !studentUUIDRegex.MatchString(uuidClaims.UUID) || c.Writer.Status() != http.StatusOK {
+		return
+	}
+
+	// only first read time is kept if student read announcement several times
+	key := announcementReadsKey(c.Param("announcement_uuid"))
+	member := &redis.Z{Score: float64(time.Now().Unix()), Member: uuidClaims.UUID}
+	if err := h.redisClient.ZAddNX(ctx, key, member).Err(); err != nil {
+		inAdvanceEntry, _ := c.Get("RequestLogEntry")
+		entry, _ := inAdvanceEntry.(*logrus.Entry)
+		entry.WithFields(logrus.Fields{"user_uuid": uuidClaims.UUID, "message": fmt.Sprintf("unable to record read receipt of announcement, err: %v", err)}).Error()
+	}
+}
+
+// get read & unread students in target audience of school announcement, only writer of announcement & admin can get it
+func (h *_default) GetAnnouncementReadReceipts(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	announcementUUID := c.Param("announcement_uuid")
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) {
+		if err := h.checkAnnouncementWriter(reqID, topSpan, uuidClaims.UUID, announcementUUID); err != nil {
+			status, _code, msg := http.StatusForbidden, 0, "only writer of announcement & admin can get read receipts of announcement"
+			switch err {
+			case errAnnouncementNotWritten:
+			case errTooManyMyAnnouncements:
+				status, msg = http.StatusConflict, err.Error()
+			default:
+				status, _code, msg = h.getStatusCodeFromCallErr("GetMyAnnouncements", err)
+			}
+			c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "announcement_uuid": announcementUUID}).Info()
+			return
+		}
+	}
+
+	var rpcResp *announcementproto.GetAnnouncementDetailResponse
+	err := h.callServiceInSpan(topic.AnnouncementServiceName, "GetAnnouncementDetail", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcReq := &announcementproto.GetAnnouncementDetailRequest{Uuid: uuidClaims.UUID, AnnouncementId: announcementUUID}
+		rpcResp, rpcErr = h.announcementService.GetAnnouncementDetail(ctx, rpcReq, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetAnnouncementDetail", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "announcement_uuid": announcementUUID}).Error()
+		return
+	}
+	if rpcResp.Status != http.StatusOK {
+		c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
+		entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "announcement_uuid": announcementUUID}).Info()
+		return
+	}
+	if rpcResp.AnnouncementType != "school" {
+		status, _code, msg := http.StatusConflict, 0, "read receipts can be got only in school announcement"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "announcement_uuid": announcementUUID}).Info()
+		return
+	}
+
+	audience, err := h.getAnnouncementAudience(reqID, topSpan, uuidClaims.UUID, fmt.Sprint(rpcResp.TargetGrade), fmt.Sprint(rpcResp.TargetGroup))
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetStudentUUIDsWithInform", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "announcement_uuid": announcementUUID}).Error()
+		return
+	}
+
+	receipts, err := h.redisClient.ZRangeWithScores(ctx, announcementReadsKey(announcementUUID), 0, -1).Result()
+	if err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to get read receipts of announcement, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "announcement_uuid": announcementUUID}).Error()
+		return
+	}
+
+	// receipts of students out of target audience (ex. audience is changed after reading) are ignored
+	read := make([]gin.H, 0, len(receipts))
+	readUUIDs := map[string]bool{}
+	for _, receipt := range receipts {
+		studentUUID, _ := receipt.Member.(string)
+		if !audience[studentUUID] {
+			continue
+		}
+		readUUIDs[studentUUID] = true
+		read = append(read, gin.H{"student_uuid": studentUUID, "read_at": time.Unix(int64(receipt.Score), 0).In(h.location).Format(time.RFC3339)})
+	}
+	unread := make([]string, 0, len(audience)-len(read))
+	for studentUUID := range audience {
+		if !readUUIDs[studentUUID] {
+			unread = append(unread, studentUUID)
+		}
+	}
+	sort.Strings(unread)
+
+	status, _code := http.StatusOK, 0
+	msg := "succeed to get read receipts of announcement"
+	sendResp := gin.H{"status": status, "code": _code, "message": msg, "target_num": len(audience), "read_num": len(read),
+		"unread_num": len(unread), "read": read, "unread": unread}
+	c.JSON(status, sendResp)
+	respBytes, _ := json.Marshal(sendResp)
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "announcement_uuid": announcementUUID}).Info()
+}
+
+// check if announcement is written by user with pages of announcements of user, errAnnouncementNotWritten is returned if not
+// errTooManyMyAnnouncements is returned if announcement is not found until max number of pages
+func (h *_default) checkAnnouncementWriter(reqID string, topSpan opentracing.Span, writerUUID, announcementUUID string) (err error) {
+	for page := 0; page < maxMyAnnouncementsPages; page++ {
+		var rpcResp *announcementproto.GetAnnouncementsResponse
+		err = h.callServiceInSpan(topic.AnnouncementServiceName, "GetMyAnnouncements", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcReq := &announcementproto.GetMyAnnouncementsRequest{Uuid: writerUUID, Start: int32(page * myAnnouncementsPageSize), Count: myAnnouncementsPageSize}
+			rpcResp, rpcErr = h.announcementService.GetMyAnnouncements(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		if err == nil && rpcResp.Status != http.StatusOK {
+			err = errors.New(fmt.Sprintf("GetMyAnnouncements responses with %d status, msg: %s", rpcResp.Status, rpcResp.Msg))
+		}
+		if err != nil {
+			return
+		}
+
+		for _, announcement := range rpcResp.Announcement {
+			if announcement.AnnouncementId == announcementUUID {
+				return nil
+			}
+		}
+		if len(rpcResp.Announcement) < myAnnouncementsPageSize {
+			return errAnnouncementNotWritten
+		}
+	}
+	return errTooManyMyAnnouncements
+}
+
+// get set of uuid of students in target audience, each digit of target grade & group is grade & group in audience (ex. 13 -> 1, 3)
+// target of 0 means every grade or group, GetStudentUUIDsWithInform is called per pair of grade & group
+func (h *_default) getAnnouncementAudience(reqID string, topSpan opentracing.Span, uuid, targetGrade, targetGroup string) (audience map[string]bool, err error) {
+	type class struct{ grade, group int }
+	var classes []class
+	for _, grade := range targetGrade {
+		for _, group := range targetGroup {
+			gradeNum, _ := strconv.Atoi(string(grade))
+			groupNum, _ := strconv.Atoi(string(group))
+			classes = append(classes, class{grade: gradeNum, group: groupNum})
+		}
+	}
+
+	studentUUIDs := make([][]string, len(classes))
+	errs := make([]error, len(classes))
+	forEachConcurrently(len(classes), maxConcurrentAudienceQueries, func(i int) {
+		var rpcResp *authproto.GetStudentUUIDsWithInformResponse
+		errs[i] = h.callServiceInSpan(topic.AuthServiceName, "GetStudentUUIDsWithInform", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcReq := &authproto.GetStudentUUIDsWithInformRequest{UUID: uuid, Grade: uint32(classes[i].grade), Group: uint32(classes[i].group)}
+			rpcResp, rpcErr = h.authService.GetStudentUUIDsWithInform(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		switch {
+		case errs[i] != nil:
+		case rpcResp.Status == http.StatusOK:
+			studentUUIDs[i] = rpcResp.StudentUUIDs
+		case rpcResp.Status == http.StatusNotFound:
+			// there is no student in class
+		default:
+			errs[i] = errors.New(fmt.Sprintf("GetStudentUUIDsWithInform responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+		}
+	})
+
+	audience = map[string]bool{}
+	for i := range classes {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		for _, studentUUID := range studentUUIDs[i] {
+			audience[studentUUID] = true
+		}
+	}
+	return
+}
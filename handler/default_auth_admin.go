@@ -44,7 +44,7 @@ func (h *_default) CreateNewStudent(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.CreateNewStudentRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -55,12 +55,12 @@ func (h *_default) CreateNewStudent(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.CreateNewStudentResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "CreateNewStudent", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("CreateNewStudent", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -68,7 +68,9 @@ func (h *_default) CreateNewStudent(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.UUID = uuidClaims.UUID
 		callOpts := []client.CallOption{client.WithDialTimeout(time.Second * 2), client.WithRequestTimeout(time.Second * 6), client.WithAddress(selectedNode.Address)}
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.CreateNewStudent(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -93,11 +95,12 @@ func (h *_default) CreateNewStudent(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to CreateNewStudent service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("CreateNewStudent returns unexpected type of error, err: %s", rpcErr.Error())
@@ -147,7 +150,7 @@ func (h *_default) CreateNewParent(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.CreateNewParentRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -158,12 +161,12 @@ func (h *_default) CreateNewParent(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.CreateNewParentResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "CreateNewParent", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("CreateNewParent", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -171,7 +174,9 @@ func (h *_default) CreateNewParent(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.UUID = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.CreateNewParent(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -196,11 +201,12 @@ func (h *_default) CreateNewParent(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to CreateNewParent service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("CreateNewParent returns unexpected type of error, err: %s", rpcErr.Error())
@@ -245,7 +251,7 @@ func (h *_default) LoginAdminAuth(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.LoginAdminAuthRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -256,19 +262,21 @@ func (h *_default) LoginAdminAuth(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.LoginAdminAuthResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "LoginAdminAuth", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("LoginAdminAuth", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.LoginAdminAuth(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -293,11 +301,12 @@ func (h *_default) LoginAdminAuth(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to LoginAdminAuth service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("LoginAdminAuth returns unexpected type of error, err: %s", rpcErr.Error())
@@ -356,7 +365,7 @@ func (h *_default) SendJoinSMSToUnsignedStudents(c *gin.Context) {
 	reqBytes, _ := json.Marshal(receivedReq)
 
 	// get service node
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -365,17 +374,29 @@ func (h *_default) SendJoinSMSToUnsignedStudents(c *gin.Context) {
 	}
 	entry = entry.WithField("SelectedNode", *selectedNode)
 
-	// send gRPC request
-	authSrvSpan := h.tracer.StartSpan("SendJoinSMSToUnsignedStudents", opentracing.ChildOf(topSpan.Context()))
-	ctxForReq := context.Background()
-	ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
-	ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
-	rpcReq := receivedReq.GenerateGRPCRequest()
-	rpcReq.UUID = uuidClaims.UUID
-	callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
-	rpcResp, rpcErr := h.authService.SendJoinSMSToUnsignedStudents(ctxForReq, rpcReq, callOpts...)
-	authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
-	authSrvSpan.Finish()
+	h.mutex.Lock()
+	if _, ok := h.breakers[selectedNode.Id]; !ok {
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
+	}
+	h.mutex.Unlock()
+
+	// send gRPC request in bulkhead & circuit breaker with tracking call to node (change in v.1.0.5)
+	var rpcResp *authproto.SendJoinSMSToUnsignedStudentsResponse
+	err = h.runInBulkhead(topic.AuthServiceName, "SendJoinSMSToUnsignedStudents", h.breakers[selectedNode.Id], func() (rpcErr error) {
+		authSrvSpan := h.tracer.StartSpan("SendJoinSMSToUnsignedStudents", opentracing.ChildOf(topSpan.Context()))
+		ctxForReq := context.Background()
+		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
+		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
+		rpcReq := receivedReq.GenerateGRPCRequest()
+		rpcReq.UUID = uuidClaims.UUID
+		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
+		rpcResp, rpcErr = h.authService.SendJoinSMSToUnsignedStudents(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
+		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
+		authSrvSpan.Finish()
+		return
+	})
 
 	switch rpcErr := err.(type) {
 	case nil:
@@ -394,8 +415,18 @@ func (h *_default) SendJoinSMSToUnsignedStudents(c *gin.Context) {
 		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
 		return
 	default:
-		status, _code := http.StatusInternalServerError, 0
-		msg := fmt.Sprintf("SendJoinSMSToUnsignedStudents returns unexpected type of error, err: %s", rpcErr.Error())
+		status, _code, msg := 0, 0, ""
+		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to SendJoinSMSToUnsignedStudents service, please try again later"
+		case breaker.ErrBreakerOpen:
+			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
+			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
+		default:
+			status, _code = http.StatusInternalServerError, 0
+			msg = fmt.Sprintf("SendJoinSMSToUnsignedStudents returns unexpected type of error, err: %s", rpcErr.Error())
+		}
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
 		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
 		return
@@ -39,7 +39,7 @@ func (h *_default) LoginParentAuth(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.LoginParentAuthRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -50,19 +50,21 @@ func (h *_default) LoginParentAuth(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.LoginParentAuthResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "LoginParentAuth", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("LoginParentAuth", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.LoginParentAuth(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -87,11 +89,12 @@ func (h *_default) LoginParentAuth(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to LoginParentAuth service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("LoginParentAuth returns unexpected type of error, err: %s", rpcErr.Error())
@@ -149,7 +152,7 @@ func (h *_default) ChangeParentPW(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.ChangeParentPWRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -160,12 +163,12 @@ func (h *_default) ChangeParentPW(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.ChangeParentPWResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "ChangeParentPW", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("ChangeParentPW", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -174,7 +177,9 @@ func (h *_default) ChangeParentPW(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.ParentUUID = c.Param("parent_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.ChangeParentPW(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -199,11 +204,12 @@ func (h *_default) ChangeParentPW(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to ChangeParentPW service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("ChangeParentPW returns unexpected type of error, err: %s", rpcErr.Error())
@@ -248,7 +254,7 @@ func (h *_default) GetParentInformWithUUID(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -259,12 +265,12 @@ func (h *_default) GetParentInformWithUUID(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetParentInformWithUUIDResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetParentInformWithUUID", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetParentInformWithUUID", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -273,7 +279,9 @@ func (h *_default) GetParentInformWithUUID(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.ParentUUID = c.Param("parent_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetParentInformWithUUID(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -298,11 +306,12 @@ func (h *_default) GetParentInformWithUUID(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetParentInformWithUUID service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetParentInformWithUUID returns unexpected type of error, err: %s", rpcErr.Error())
@@ -355,7 +364,7 @@ func (h *_default) GetParentUUIDsWithInform(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetParentUUIDsWithInformRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -366,12 +375,12 @@ func (h *_default) GetParentUUIDsWithInform(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetParentUUIDsWithInformResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetParentUUIDsWithInform", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetParentUUIDsWithInform", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -379,7 +388,9 @@ func (h *_default) GetParentUUIDsWithInform(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.UUID = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetParentUUIDsWithInform(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -404,11 +415,12 @@ func (h *_default) GetParentUUIDsWithInform(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetParentUUIDsWithInform service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetParentUUIDsWithInform returns unexpected type of error, err: %s", rpcErr.Error())
@@ -453,7 +465,7 @@ func (h *_default) GetChildrenInformsWithUUID(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -464,12 +476,12 @@ func (h *_default) GetChildrenInformsWithUUID(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetChildrenInformsWithUUIDResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetChildrenInformsWithUUID", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetChildrenInformsWithUUID", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -478,7 +490,9 @@ func (h *_default) GetChildrenInformsWithUUID(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.ParentUUID = c.Param("parent_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetChildrenInformsWithUUID(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -503,11 +517,12 @@ func (h *_default) GetChildrenInformsWithUUID(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetChildrenInformsWithUUID service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetChildrenInformsWithUUID returns unexpected type of error, err: %s", rpcErr.Error())
@@ -39,7 +39,7 @@ func (h *_default) LoginStudentAuth(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.LoginStudentAuthRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -50,19 +50,21 @@ func (h *_default) LoginStudentAuth(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.LoginStudentAuthResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "LoginStudentAuth", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("LoginStudentAuth", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.LoginStudentAuth(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -87,11 +89,12 @@ func (h *_default) LoginStudentAuth(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to LoginStudentAuth service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("LoginStudentAuth returns unexpected type of error, err: %s", rpcErr.Error())
@@ -149,7 +152,7 @@ func (h *_default) ChangeStudentPW(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.ChangeStudentPWRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -160,12 +163,12 @@ func (h *_default) ChangeStudentPW(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.ChangeStudentPWResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "ChangeStudentPW", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("ChangeStudentPW", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -174,7 +177,9 @@ func (h *_default) ChangeStudentPW(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.StudentUUID = c.Param("student_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.ChangeStudentPW(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -199,11 +204,12 @@ func (h *_default) ChangeStudentPW(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to ChangeStudentPW service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("ChangeStudentPW returns unexpected type of error, err: %s", rpcErr.Error())
@@ -248,7 +254,7 @@ func (h *_default) GetStudentInformWithUUID(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -259,12 +265,12 @@ func (h *_default) GetStudentInformWithUUID(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetStudentInformWithUUIDResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetStudentInformWithUUID", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetStudentInformWithUUID", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -273,7 +279,9 @@ func (h *_default) GetStudentInformWithUUID(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.StudentUUID = c.Param("student_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetStudentInformWithUUID(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -298,11 +306,12 @@ func (h *_default) GetStudentInformWithUUID(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetStudentInformWithUUID service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetStudentInformWithUUID returns unexpected type of error, err: %s", rpcErr.Error())
@@ -356,7 +365,7 @@ func (h *_default) GetStudentUUIDsWithInform(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetStudentUUIDsWithInformRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -367,12 +376,12 @@ func (h *_default) GetStudentUUIDsWithInform(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetStudentUUIDsWithInformResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetStudentUUIDsWithInform", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetStudentUUIDsWithInform", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -380,7 +389,9 @@ func (h *_default) GetStudentUUIDsWithInform(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.UUID = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetStudentUUIDsWithInform(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -405,11 +416,12 @@ func (h *_default) GetStudentUUIDsWithInform(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetStudentUUIDsWithInform service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetStudentUUIDsWithInform returns unexpected type of error, err: %s", rpcErr.Error())
@@ -459,7 +471,7 @@ func (h *_default) GetStudentInformsWithUUIDs(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetStudentInformsWithUUIDsRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -470,12 +482,12 @@ func (h *_default) GetStudentInformsWithUUIDs(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetStudentInformsWithUUIDsResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetStudentInformsWithUUIDs", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetStudentInformsWithUUIDs", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -483,7 +495,9 @@ func (h *_default) GetStudentInformsWithUUIDs(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.UUID = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetStudentInformsWithUUIDs(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -508,11 +522,12 @@ func (h *_default) GetStudentInformsWithUUIDs(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetStudentInformsWithUUIDs service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetStudentInformsWithUUIDs returns unexpected type of error, err: %s", rpcErr.Error())
@@ -569,7 +584,7 @@ func (h *_default) GetParentWithStudentUUID(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -580,12 +595,12 @@ func (h *_default) GetParentWithStudentUUID(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetParentWithStudentUUIDResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetParentWithStudentUUID", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetParentWithStudentUUID", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -594,7 +609,9 @@ func (h *_default) GetParentWithStudentUUID(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.StudentUUID = c.Param("student_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetParentWithStudentUUID(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -619,11 +636,12 @@ func (h *_default) GetParentWithStudentUUID(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetParentWithStudentUUID service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetParentWithStudentUUID returns unexpected type of error, err: %s", rpcErr.Error())
@@ -671,7 +689,7 @@ func (h *_default) GetUnsignedStudentWithAuthCode(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetUnsignedStudentWithAuthCodeRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -682,19 +700,21 @@ func (h *_default) GetUnsignedStudentWithAuthCode(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetUnsignedStudentWithAuthCodeResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetStudentInformWithAuthCode", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetStudentInformWithAuthCode", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetUnsignedStudentWithAuthCode(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -719,11 +739,12 @@ func (h *_default) GetUnsignedStudentWithAuthCode(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetStudentInformWithAuthCode service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetStudentInformWithAuthCode returns unexpected type of error, err: %s", rpcErr.Error())
@@ -771,7 +792,7 @@ func (h *_default) CreateNewStudentWithAuthCode(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.CreateNewStudentWithAuthCodeRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -782,19 +803,21 @@ func (h *_default) CreateNewStudentWithAuthCode(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.CreateNewStudentWithAuthCodeResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "CreateNewStudentWithAuthCode", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("CreateNewStudentWithAuthCode", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.CreateNewStudentWithAuthCode(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -819,11 +842,12 @@ func (h *_default) CreateNewStudentWithAuthCode(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to CreateNewStudentWithAuthCode service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("CreateNewStudentWithAuthCode returns unexpected type of error, err: %s", rpcErr.Error())
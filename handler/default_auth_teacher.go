@@ -39,7 +39,7 @@ func (h *_default) CreateNewTeacher(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.CreateNewTeacherRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -50,19 +50,21 @@ func (h *_default) CreateNewTeacher(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.CreateNewTeacherResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "CreateNewTeacher", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("CreateNewTeacher", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.CreateNewTeacher(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -87,11 +89,12 @@ func (h *_default) CreateNewTeacher(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to CreateNewTeacher service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("CreateNewTeacher returns unexpected type of error, err: %s", rpcErr.Error())
@@ -136,7 +139,7 @@ func (h *_default) LoginTeacherAuth(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.LoginTeacherAuthRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -147,19 +150,21 @@ func (h *_default) LoginTeacherAuth(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.LoginTeacherAuthResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "LoginTeacherAuth", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("LoginTeacherAuth", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.LoginTeacherAuth(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -184,11 +189,12 @@ func (h *_default) LoginTeacherAuth(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to LoginTeacherAuth service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("LoginTeacherAuth returns unexpected type of error, err: %s", rpcErr.Error())
@@ -246,7 +252,7 @@ func (h *_default) ChangeTeacherPW(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.ChangeTeacherPWRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -257,12 +263,12 @@ func (h *_default) ChangeTeacherPW(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.ChangeTeacherPWResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "ChangeTeacherPW", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("ChangeTeacherPW", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -271,7 +277,9 @@ func (h *_default) ChangeTeacherPW(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.TeacherUUID = c.Param("teacher_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.ChangeTeacherPW(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -296,11 +304,12 @@ func (h *_default) ChangeTeacherPW(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to ChangeTeacherPW service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("ChangeTeacherPW returns unexpected type of error, err: %s", rpcErr.Error())
@@ -345,7 +354,7 @@ func (h *_default) GetTeacherInformWithUUID(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -356,12 +365,12 @@ func (h *_default) GetTeacherInformWithUUID(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetTeacherInformWithUUIDResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetTeacherInformWithUUID", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetTeacherInformWithUUID", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -370,7 +379,9 @@ func (h *_default) GetTeacherInformWithUUID(c *gin.Context) {
 		rpcReq.UUID = uuidClaims.UUID
 		rpcReq.TeacherUUID = c.Param("teacher_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetTeacherInformWithUUID(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -395,11 +406,12 @@ func (h *_default) GetTeacherInformWithUUID(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetTeacherInformWithUUID service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetTeacherInformWithUUID returns unexpected type of error, err: %s", rpcErr.Error())
@@ -453,7 +465,7 @@ func (h *_default) GetTeacherUUIDsWithInform(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetTeacherUUIDsWithInformRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -464,12 +476,12 @@ func (h *_default) GetTeacherUUIDsWithInform(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *authproto.GetTeacherUUIDsWithInformResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.AuthServiceName, "GetTeacherUUIDsWithInform", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		authSrvSpan := h.tracer.StartSpan("GetTeacherUUIDsWithInform", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -477,7 +489,9 @@ func (h *_default) GetTeacherUUIDsWithInform(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.UUID = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
 		rpcResp, rpcErr = h.authService.GetTeacherUUIDsWithInform(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		authSrvSpan.Finish()
 		return
@@ -502,11 +516,12 @@ func (h *_default) GetTeacherUUIDsWithInform(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetTeacherUUIDsWithInform service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetTeacherUUIDsWithInform returns unexpected type of error, err: %s", rpcErr.Error())
@@ -0,0 +1,86 @@
+// add file in v.1.0.5
+// default_breaker.go is file to declare nodeBreaker wrapping breaker.Breaker to observe state of breaker
+
+package handler
+
+import (
+	"github.com/eapache/go-resiliency/breaker"
+	"sync"
+	"time"
+)
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// nodeBreaker is breaker of service node, recording state observed with result of Run
+// breaker.Breaker doesn't expose its state, so state is inferred from errors returned by Run
+type nodeBreaker struct {
+	*breaker.Breaker
+	config   BreakerConfig
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+	lastErr  error
+}
+
+// breakerState is state of nodeBreaker to show in admin API
+type breakerState struct {
+	State    string    `json:"state"`
+	Failures int       `json:"failures"`
+	OpenedAt time.Time `json:"opened_at"`
+	LastErr  string    `json:"last_error"`
+}
+
+func newNodeBreaker(config BreakerConfig) *nodeBreaker {
+	return &nodeBreaker{
+		Breaker: breaker.New(config.ErrorThreshold, config.SuccessThreshold, config.Timeout),
+		config:  config,
+		mutex:   sync.Mutex{},
+	}
+}
+
+// run work in breaker.Breaker & record result of work
+func (b *nodeBreaker) Run(work func() error) (err error) {
+	err = b.Breaker.Run(work)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch err {
+	case nil:
+		b.failures = 0
+		b.openedAt = time.Time{}
+	case breaker.ErrBreakerOpen:
+		if b.openedAt.IsZero() {
+			b.openedAt = time.Now()
+		}
+	default:
+		b.lastErr = err
+		if b.failures++; b.failures >= b.config.ErrorThreshold && b.openedAt.IsZero() {
+			b.openedAt = time.Now()
+		}
+	}
+	return
+}
+
+// return state observed with result of Run
+func (b *nodeBreaker) state() (state breakerState) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	state = breakerState{State: breakerClosed, Failures: b.failures, OpenedAt: b.openedAt}
+	if b.lastErr != nil {
+		state.LastErr = b.lastErr.Error()
+	}
+	switch {
+	case b.openedAt.IsZero():
+	case time.Since(b.openedAt) < b.config.Timeout:
+		state.State = breakerOpen
+	default:
+		state.State = breakerHalfOpen
+	}
+	return
+}
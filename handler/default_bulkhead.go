@@ -0,0 +1,181 @@
+// add file in v.1.0.5
+// default_bulkhead.go is file to declare bulkhead limiting in-flight requests per backend service (and method)
+// it prevents slow service from exhausting goroutines & sockets used to call other services
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"gateway/consul"
+	"sync/atomic"
+	"time"
+)
+
+// queue timeout used if it is not set in bulkhead config
+const defaultBulkheadQueueTimeout = time.Millisecond * 500
+
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+type BulkheadConfig struct {
+	MaxConcurrent int           // max number of in-flight requests
+	MaxQueue      int           // max number of requests waiting for in-flight slot
+	QueueTimeout  time.Duration // max duration to wait in queue
+}
+
+// parse bulkhead configs per service or method from JSON, queue timeout is parsed with time.ParseDuration
+// default queue timeout is used if it is omitted, because zero timeout rejects every request in queue at once
+// ex) {"DMS.SMS.v1.service.club": {"max_concurrent": 30, "max_queue": 10, "queue_timeout": "300ms"}}
+func ParseBulkheadConfigs(data []byte) (configs map[string]BulkheadConfig, err error) {
+	var parsed map[string]struct {
+		MaxConcurrent int    `json:"max_concurrent"`
+		MaxQueue      int    `json:"max_queue"`
+		QueueTimeout  string `json:"queue_timeout"`
+	}
+	if err = json.Unmarshal(data, &parsed); err != nil {
+		err = errors.New(fmt.Sprintf("unable to unmarshal bulkhead configs, err: %v", err))
+		return
+	}
+
+	configs = map[string]BulkheadConfig{}
+	for key, p := range parsed {
+		if p.MaxConcurrent <= 0 || p.MaxQueue < 0 {
+			err = errors.New(fmt.Sprintf("max concurrent must be positive & max queue must not be negative, key: %s", key))
+			return
+		}
+		timeout := defaultBulkheadQueueTimeout
+		if p.QueueTimeout != "" {
+			var parseErr error
+			if timeout, parseErr = time.ParseDuration(p.QueueTimeout); parseErr != nil {
+				err = errors.New(fmt.Sprintf("unable to parse queue timeout, key: %s, err: %v", key, parseErr))
+				return
+			}
+		}
+		configs[key] = BulkheadConfig{MaxConcurrent: p.MaxConcurrent, MaxQueue: p.MaxQueue, QueueTimeout: timeout}
+	}
+	return
+}
+
+// bulkhead is semaphore with bounded wait queue, counting requests to report in admin API & expvar
+type bulkhead struct {
+	config    BulkheadConfig
+	slots     chan struct{}
+	queued    int64
+	completed int64
+	rejected  int64
+}
+
+// bulkheadState is state of bulkhead to show in admin API
+type bulkheadState struct {
+	MaxConcurrent int    `json:"max_concurrent"`
+	MaxQueue      int    `json:"max_queue"`
+	QueueTimeout  string `json:"queue_timeout"`
+	InFlight      int    `json:"in_flight"`
+	Queued        int64  `json:"queued"`
+	Completed     int64  `json:"completed"`
+	Rejected      int64  `json:"rejected"`
+}
+
+func newBulkhead(config BulkheadConfig) *bulkhead {
+	return &bulkhead{
+		config: config,
+		slots:  make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// acquire in-flight slot, waiting in queue if there is no slot & queue is not full
+// return func releasing acquired slot, or ErrBulkheadFull if slot can't be acquired
+func (b *bulkhead) acquire() (release func(), err error) {
+	release = func() {
+		<-b.slots
+		atomic.AddInt64(&b.completed, 1)
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return
+	default:
+	}
+
+	if queued := atomic.AddInt64(&b.queued, 1); queued > int64(b.config.MaxQueue) {
+		atomic.AddInt64(&b.queued, -1)
+		atomic.AddInt64(&b.rejected, 1)
+		return nil, ErrBulkheadFull
+	}
+	defer atomic.AddInt64(&b.queued, -1)
+
+	timer := time.NewTimer(b.config.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return
+	case <-timer.C:
+		atomic.AddInt64(&b.rejected, 1)
+		return nil, ErrBulkheadFull
+	}
+}
+
+func (b *bulkhead) state() bulkheadState {
+	return bulkheadState{
+		MaxConcurrent: b.config.MaxConcurrent,
+		MaxQueue:      b.config.MaxQueue,
+		QueueTimeout:  b.config.QueueTimeout.String(),
+		InFlight:      len(b.slots),
+		Queued:        atomic.LoadInt64(&b.queued),
+		Completed:     atomic.LoadInt64(&b.completed),
+		Rejected:      atomic.LoadInt64(&b.rejected),
+	}
+}
+
+// run work in breaker of node after acquiring slot of bulkhead, so that rejection in bulkhead isn't counted in breaker
+func (h *_default) runInBulkhead(service consul.ServiceName, method string, b *nodeBreaker, work func() error) error {
+	release, err := h.bulkheadOf(service, method).acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return b.Run(work)
+}
+
+// return bulkhead of method if config of method is set in BulkheadCfgs, or return bulkhead of service
+// config key of method is "{service}/{method}", and config key of service is "{service}"
+func (h *_default) bulkheadOf(service consul.ServiceName, method string) *bulkhead {
+	key := fmt.Sprintf("%s/%s", service, method)
+	config, ok := h.BulkheadCfgs[key]
+	if !ok {
+		key = string(service)
+		if config, ok = h.BulkheadCfgs[key]; !ok {
+			config = h.BulkheadCfg
+		}
+	}
+
+	h.bulkheadMutex.Lock()
+	defer h.bulkheadMutex.Unlock()
+	if _, ok := h.bulkheads[key]; !ok {
+		h.bulkheads[key] = newBulkhead(config)
+	}
+	return h.bulkheads[key]
+}
+
+// return state of all bulkheads created until now
+func (h *_default) bulkheadStates() map[string]bulkheadState {
+	h.bulkheadMutex.Lock()
+	defer h.bulkheadMutex.Unlock()
+
+	var states = map[string]bulkheadState{}
+	for key, b := range h.bulkheads {
+		states[key] = b.state()
+	}
+	return states
+}
+
+// BulkheadsVar return expvar.Var reporting state of all bulkheads, to be published & exported as metrics in admin API
+func (h *_default) BulkheadsVar() expvar.Var {
+	return expvar.Func(func() interface{} {
+		return h.bulkheadStates()
+	})
+}
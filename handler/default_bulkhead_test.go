@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseBulkheadConfigs(t *testing.T) {
+	configs, err := ParseBulkheadConfigs([]byte(`{
+		"DMS.SMS.v1.service.club": {"max_concurrent": 30, "max_queue": 10, "queue_timeout": "300ms"},
+		"DMS.SMS.v1.service.auth/LoginAuth": {"max_concurrent": 5, "max_queue": 0, "queue_timeout": "0s"},
+		"DMS.SMS.v1.service.outing": {"max_concurrent": 20, "max_queue": 5}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]BulkheadConfig{
+		"DMS.SMS.v1.service.club":           {MaxConcurrent: 30, MaxQueue: 10, QueueTimeout: time.Millisecond * 300},
+		"DMS.SMS.v1.service.auth/LoginAuth": {MaxConcurrent: 5, MaxQueue: 0, QueueTimeout: 0},
+		"DMS.SMS.v1.service.outing":         {MaxConcurrent: 20, MaxQueue: 5, QueueTimeout: defaultBulkheadQueueTimeout},
+	}, configs)
+
+	for _, data := range []string{
+		`[]`,
+		`{"club": {"max_concurrent": 0, "max_queue": 10, "queue_timeout": "300ms"}}`,
+		`{"club": {"max_concurrent": 30, "max_queue": -1, "queue_timeout": "300ms"}}`,
+		`{"club": {"max_concurrent": 30, "max_queue": 10, "queue_timeout": "300"}}`,
+	} {
+		_, err := ParseBulkheadConfigs([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestBulkheadAcquire(t *testing.T) {
+	b := newBulkhead(BulkheadConfig{MaxConcurrent: 2, MaxQueue: 1, QueueTimeout: time.Millisecond * 50})
+
+	first, err := b.acquire()
+	assert.NoError(t, err)
+	_, err = b.acquire()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, b.state().InFlight)
+
+	// request in queue acquires slot released while waiting
+	acquired := make(chan error)
+	go func() {
+		_, err := b.acquire()
+		acquired <- err
+	}()
+	assert.Eventually(t, func() bool { return b.state().Queued == 1 }, time.Second, time.Millisecond)
+
+	// request is rejected immediately if queue is full
+	_, err = b.acquire()
+	assert.Equal(t, ErrBulkheadFull, err)
+
+	first()
+	assert.NoError(t, <-acquired)
+
+	// request in queue is rejected after queue timeout
+	_, err = b.acquire()
+	assert.Equal(t, ErrBulkheadFull, err)
+
+	state := b.state()
+	assert.Equal(t, 2, state.InFlight)
+	assert.Equal(t, int64(0), state.Queued)
+	assert.Equal(t, int64(1), state.Completed)
+	assert.Equal(t, int64(2), state.Rejected)
+}
@@ -0,0 +1,95 @@
+// add file in v.1.0.5
+// default_call.go is file to declare method calling service out of HTTP request, such as event publisher or background job
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"gateway/consul"
+	consulagent "gateway/consul/agent"
+	code "gateway/utils/code/golang"
+	"github.com/eapache/go-resiliency/breaker"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/metadata"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/uber/jaeger-client-go"
+	"net/http"
+)
+
+// serviceCall is function calling method of service with context & call options, and return status in response
+type serviceCall func(ctx context.Context, callOpts ...client.CallOption) (status int, err error)
+
+// call method of service with next service node, in bulkhead & circuit breaker of node same as HTTP request handler
+// status in response must be checked by caller, because returned error is only about calling
+func (h *_default) callService(service consul.ServiceName, method string, call serviceCall) (err error) {
+	return h.callServiceInSpan(service, method, "", nil, call)
+}
+
+// call method of service as child span of parent span with X-Request-Id, used to call service per item in HTTP request
+// parent span can be nil, then span of method is started as root span
+func (h *_default) callServiceInSpan(service consul.ServiceName, method, reqID string, parent opentracing.Span, call serviceCall) (err error) {
+	selectedNode, err := h.consulAgent.GetNextServiceNode(service)
+	if err != nil {
+		return
+	}
+
+	h.mutex.Lock()
+	if _, ok := h.breakers[selectedNode.Id]; !ok {
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
+	}
+	nodeBreaker := h.breakers[selectedNode.Id]
+	h.mutex.Unlock()
+
+	var spanOpts []opentracing.StartSpanOption
+	if parent != nil {
+		spanOpts = append(spanOpts, opentracing.ChildOf(parent.Context()))
+	}
+
+	err = h.runInBulkhead(service, method, nodeBreaker, func() (rpcErr error) {
+		srvSpan := h.tracer.StartSpan(method, spanOpts...)
+		ctxForReq := context.Background()
+		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
+		ctxForReq = metadata.Set(ctxForReq, "Span-Context", srvSpan.Context().(jaeger.SpanContext).String())
+		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(service, selectedNode)
+		status, rpcErr := call(ctxForReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, status))
+		srvSpan.SetTag("X-Request-Id", reqID).SetTag("status", status).LogFields(log.Error(rpcErr))
+		srvSpan.Finish()
+		return
+	})
+	return
+}
+
+// get status, code & msg from error returned by callService, in the same way as HTTP request handler
+func (h *_default) getStatusCodeFromCallErr(method string, err error) (status, _code int, msg string) {
+	switch callErr := err.(type) {
+	case *errors.Error:
+		switch callErr.Code {
+		case http.StatusRequestTimeout:
+			msg = fmt.Sprintf("request time out for %s service, detail: %s", method, callErr.Detail)
+			status, _code = http.StatusRequestTimeout, 0
+		default:
+			msg = fmt.Sprintf("%s returns unexpected micro error, code: %d, detail: %s", method, callErr.Code, callErr.Detail)
+			status, _code = http.StatusInternalServerError, 0
+		}
+	default:
+		switch callErr {
+		case consulagent.ErrAvailableNodeNotFound:
+			status, _code, msg = h.getStatusCodeFromConsulErr(err)
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = fmt.Sprintf("too many in-flight requests to %s service, please try again later", method)
+		case breaker.ErrBreakerOpen:
+			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
+			msg = fmt.Sprintf("circuit breaker is open (time out: %s)", h.BreakerCfg.Timeout.String())
+		default:
+			status, _code = http.StatusInternalServerError, 0
+			msg = fmt.Sprintf("%s returns unexpected type of error, err: %s", method, err.Error())
+		}
+	}
+	return
+}
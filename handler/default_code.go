@@ -0,0 +1,13 @@
+// add file in v.1.0.5
+// default_code.go is file that declare response codes returned only by gateway
+// they are declared here instead of code package in utils, and should be moved to there when it is updated
+
+package handler
+
+const (
+	bulkheadFullCode = -1999
+
+	outingPolicyTimeWindowCode     = -2101
+	outingPolicyQuotaCode          = -2102
+	outingPolicyParentApprovalCode = -2103
+)
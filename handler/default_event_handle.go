@@ -33,6 +33,7 @@ var (
 	// schedule regex
 	schedulesRegex = regexp.MustCompile("^schedules$")
 	timetableRegex = regexp.MustCompile("^students.student-\\d{12}.timetable.years.\\d{4}.months.\\d{1,2}.days.\\d{1,2}$")
+	allStudentsTimetableRegex = regexp.MustCompile("^students.\\*.timetable$") // add in v.1.0.5
 
 	// announcement regex
 	announcementRegex = regexp.MustCompile("^announcements.announcement-\\d{12}$")
@@ -40,6 +41,7 @@ var (
 	announcementsRegex = regexp.MustCompile("^announcements.uuid.*.types.(school|club|\\*)$")
 	announcementCheckRegex = regexp.MustCompile("^students.*.announcement-check$")
 	myAnnouncementRegex = regexp.MustCompile("^writers.*.announcements$")
+	announcementReadsRegex = regexp.MustCompile("^announcement-reads.announcement-\\d{12}$") // add in v.1.0.5
 
 	studentUUIDRegex = regexp.MustCompile("^student-\\d{12}$")
 )
@@ -138,6 +140,10 @@ func (h *_default) DeleteAssociatedRedisKey(msg *redis.Message) (err error) {
 		// ex) schedules -> schedules*
 		pattern = fmt.Sprintf("%s*", payload)
 
+	case allStudentsTimetableRegex.MatchString(payload):
+		// ex) students.*.timetable -> students.*.timetable.years.*.months.*.days.*
+		pattern = fmt.Sprintf("%s.years.*.months.*.days.*", payload)
+
 	case announcementRegex.MatchString(payload):
 		// ex) announcement.announcement-123412341234 -> ''
 		pattern = fmt.Sprintf("%s", payload)
@@ -154,6 +160,10 @@ func (h *_default) DeleteAssociatedRedisKey(msg *redis.Message) (err error) {
 		// ex) writers.student-123412341234.announcements -> writers.student-123412341234.announcements.start.*.count.*
 		pattern = fmt.Sprintf("%s.start.*.count.*", payload)
 
+	case announcementReadsRegex.MatchString(payload): // add in v.1.0.5
+		// ex) announcement-reads.announcement-123412341234 -> ''
+		pattern = fmt.Sprintf("%s", payload)
+
 	default:
 		err = errors.New(fmt.Sprintf("message does not match any regular expressions, msg payload: %s", payload))
 		return
@@ -0,0 +1,156 @@
+// add file in v.1.0.5
+// default_health.go is file that declare handler of liveness & readiness probe used in kubernetes and AWS load balancer
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"gateway/consul"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	componentUp   = "up"
+	componentDown = "down"
+)
+
+// timeout of each dependency check in readiness probe
+const readinessCheckTimeout = time.Second * 2
+
+// max ratio of open circuit breakers to regard gateway as ready
+const maxOpenBreakerRatio = 0.5
+
+// services which must have at least one available node to regard gateway as ready
+var criticalServices = []consul.ServiceName{topic.AuthServiceName, topic.OutingServiceName}
+
+// interface to check liveness of listeners started by subscriber
+type ListenerChecker interface {
+	AliveListeners() (alive, total int)
+}
+
+// componentHealth is result of checking one component in readiness probe
+type componentHealth struct {
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// respond that process is alive, without checking any dependency
+func (h *_default) Healthz(c *gin.Context) {
+	status, _code := http.StatusOK, 0
+	msg := "gateway process is alive"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+}
+
+// return handler checking redis, consul, critical service nodes, subscriber listeners & circuit breakers
+// respond 503 if any of component is down, with detail per component
+func (h *_default) Readyz(listeners ListenerChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		components := map[string]componentHealth{
+			"redis":      h.checkRedisHealth(),
+			"consul":     h.checkConsulHealth(),
+			"subscriber": checkListenerHealth(listeners),
+			"breakers":   h.checkBreakerHealth(),
+		}
+		for service, health := range h.checkServiceNodeHealth() {
+			components[fmt.Sprintf("service:%s", service)] = health
+		}
+
+		status, _code := http.StatusOK, 0
+		msg := "gateway is ready to receive request"
+		names := make([]string, 0, len(components))
+		for name := range components {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if components[name].Status == componentDown {
+				status = http.StatusServiceUnavailable
+				msg = fmt.Sprintf("gateway is not ready, %s component is down", name)
+				break
+			}
+		}
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "components": components})
+	}
+}
+
+func (h *_default) checkRedisHealth() componentHealth {
+	ctxForPing, cancel := context.WithTimeout(context.Background(), readinessCheckTimeout)
+	defer cancel()
+
+	if err := h.redisClient.Ping(ctxForPing).Err(); err != nil {
+		return componentHealth{Status: componentDown, Detail: fmt.Sprintf("unable to ping redis, err: %v", err)}
+	}
+	return componentHealth{Status: componentUp, Detail: "succeed to ping redis"}
+}
+
+func (h *_default) checkConsulHealth() componentHealth {
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- h.consulAgent.PingConsul()
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return componentHealth{Status: componentDown, Detail: err.Error()}
+		}
+		return componentHealth{Status: componentUp, Detail: "succeed to ping consul"}
+	case <-time.After(readinessCheckTimeout):
+		return componentHealth{Status: componentDown, Detail: "timeout while pinging consul"}
+	}
+}
+
+// check if each critical service has at least one node which is not ejected or forced out
+func (h *_default) checkServiceNodeHealth() map[consul.ServiceName]componentHealth {
+	states := h.consulAgent.GetServiceNodeStates()
+
+	healths := map[consul.ServiceName]componentHealth{}
+	for _, service := range criticalServices {
+		available := 0
+		for _, state := range states[service] {
+			if !state.Ejected && !state.ForcedOut {
+				available++
+			}
+		}
+
+		detail := fmt.Sprintf("available node num: %d, all node num: %d", available, len(states[service]))
+		if available == 0 {
+			healths[service] = componentHealth{Status: componentDown, Detail: detail}
+		} else {
+			healths[service] = componentHealth{Status: componentUp, Detail: detail}
+		}
+	}
+	return healths
+}
+
+// check if ratio of open circuit breakers doesn't exceed maxOpenBreakerRatio
+func (h *_default) checkBreakerHealth() componentHealth {
+	h.mutex.Lock()
+	open, total := 0, len(h.breakers)
+	for _, b := range h.breakers {
+		if b.state().State == breakerOpen {
+			open++
+		}
+	}
+	h.mutex.Unlock()
+
+	detail := fmt.Sprintf("open breaker num: %d, all breaker num: %d", open, total)
+	if total != 0 && float64(open)/float64(total) > maxOpenBreakerRatio {
+		return componentHealth{Status: componentDown, Detail: detail}
+	}
+	return componentHealth{Status: componentUp, Detail: detail}
+}
+
+func checkListenerHealth(listeners ListenerChecker) componentHealth {
+	alive, total := listeners.AliveListeners()
+	detail := fmt.Sprintf("alive listener num: %d, all listener num: %d", alive, total)
+	if alive < total {
+		return componentHealth{Status: componentDown, Detail: detail}
+	}
+	return componentHealth{Status: componentUp, Detail: detail}
+}
@@ -0,0 +1,303 @@
+// add file in v.1.0.5
+// default_holiday.go is file that declare calendar of days off, which are public holidays & closure days of school
+// public holidays are loaded from bundled file & reloaded periodically, and closure days are set by admin in redis
+// days off are overlaid on schedules & timetables, and can be condition of outing policy
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/entity"
+	jwtutil "gateway/tool/jwt"
+	code "gateway/utils/code/golang"
+	"github.com/gin-gonic/gin"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// hash of reason of closure day per date (2006-01-02), it must not start with "schedules" not to be deleted with schedules
+const schoolClosuresKey = "calendar.closures"
+
+// max number of days in range of GET /v1/calendar/days-off
+const maxDaysOffRange = 366
+
+// type of day off
+const (
+	dayOffTypeHoliday = "holiday"
+	dayOffTypeClosure = "closure"
+)
+
+// HolidayCalendar is content of holiday file, date of holiday is 2006-01-02 (only that year) or 01-02 (every year)
+type HolidayCalendar struct {
+	Holidays []struct {
+		Date string `json:"date" yaml:"date"`
+		Name string `json:"name" yaml:"name"`
+	} `json:"holidays" yaml:"holidays"`
+}
+
+// dayOff is public holiday or closure day of school, closure day overrides holiday in same date
+type dayOff struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// function that return closure loading holiday file & starting reloader of holidays in another goroutine
+// error is returned if holidays can't be loaded at first, but previous holidays are kept if reloading fails
+func (h *_default) HolidayLoader() func() error {
+	return func() (err error) {
+		if h.holidayFile == "" {
+			log.Info("holiday calendar is disabled, because path of holiday file is not set")
+			return
+		}
+
+		if err = h.reloadHolidays(); err != nil {
+			return
+		}
+		if h.HolidayReloadInterval == 0 {
+			return
+		}
+
+		go func() {
+			ticker := time.NewTicker(h.HolidayReloadInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := h.reloadHolidays(); err != nil {
+					log.Errorf("unable to reload holiday file, holidays are not changed, err: %v", err)
+				}
+			}
+		}()
+		log.Infof("start holiday calendar reloader!! (interval: %s)", h.HolidayReloadInterval)
+		return
+	}
+}
+
+// load holiday file (JSON or YAML) & replace holidays in use
+func (h *_default) reloadHolidays() (err error) {
+	content, err := ioutil.ReadFile(h.holidayFile)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to read holiday file, err: %v", err))
+		return
+	}
+
+	calendar := HolidayCalendar{}
+	switch filepath.Ext(h.holidayFile) {
+	case ".json":
+		err = json.Unmarshal(content, &calendar)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &calendar)
+	default:
+		err = errors.New(fmt.Sprintf("unsupported extension of holiday file, path: %s", h.holidayFile))
+	}
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to unmarshal holiday file, err: %v", err))
+		return
+	}
+
+	holidays := map[string]string{}
+	for _, holiday := range calendar.Holidays {
+		_, fullErr := time.Parse("2006-01-02", holiday.Date)
+		_, annualErr := time.Parse("01-02", holiday.Date)
+		if (fullErr != nil && annualErr != nil) || holiday.Name == "" {
+			err = errors.New(fmt.Sprintf("invalid holiday in holiday file, date: %s, name: %s", holiday.Date, holiday.Name))
+			return
+		}
+		holidays[holiday.Date] = holiday.Name
+	}
+
+	h.holidayMutex.Lock()
+	h.holidays = holidays
+	h.holidayMutex.Unlock()
+	return
+}
+
+// return days off from start to end (inclusive, in school time zone) sorted by date
+func (h *_default) daysOffBetween(start, end time.Time) (daysOff []dayOff, err error) {
+	closures, err := h.redisClient.HGetAll(ctx, schoolClosuresKey).Result()
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to get closure days of school, err: %v", err))
+		return
+	}
+
+	h.holidayMutex.RLock()
+	defer h.holidayMutex.RUnlock()
+
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, h.location)
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		key := date.Format("2006-01-02")
+		if reason, ok := closures[key]; ok {
+			daysOff = append(daysOff, dayOff{Date: key, Name: reason, Type: dayOffTypeClosure})
+		} else if name, ok := h.holidays[key]; ok {
+			daysOff = append(daysOff, dayOff{Date: key, Name: name, Type: dayOffTypeHoliday})
+		} else if name, ok := h.holidays[date.Format("01-02")]; ok {
+			daysOff = append(daysOff, dayOff{Date: key, Name: name, Type: dayOffTypeHoliday})
+		}
+	}
+	return
+}
+
+// return day off of date in school time zone, or nil if date is school day
+func (h *_default) dayOffOf(date time.Time) (*dayOff, error) {
+	date = date.In(h.location)
+	daysOff, err := h.daysOffBetween(date, date)
+	if err != nil || len(daysOff) == 0 {
+		return nil, err
+	}
+	return &daysOff[0], nil
+}
+
+// return days off in month, error is only logged because days off are overlay of schedules
+func (h *_default) daysOffInMonth(year, month int) []dayOff {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, h.location)
+	daysOff, err := h.daysOffBetween(start, start.AddDate(0, 1, -1))
+	if err != nil {
+		log.Errorf("unable to overlay days off on schedules, err: %v", err)
+	}
+	if daysOff == nil {
+		daysOff = []dayOff{}
+	}
+	return daysOff
+}
+
+// add no_class & day_off field in timetable response of date, error is only logged because day off is overlay of timetable
+func (h *_default) overlayDayOffOnTimeTable(timeTable gin.H, date time.Time) {
+	off, err := h.dayOffOf(date)
+	if err != nil {
+		log.Errorf("unable to overlay day off on timetable, err: %v", err)
+	}
+	timeTable["no_class"] = off != nil
+	if off != nil {
+		timeTable["day_off"] = off
+	}
+}
+
+// get days off (public holidays & closure days) from start date to end date
+func (h *_default) GetDaysOff(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.GetDaysOffRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	startDate, _ := time.ParseInLocation("2006-01-02", receivedReq.StartDate, h.location)
+	endDate, _ := time.ParseInLocation("2006-01-02", receivedReq.EndDate, h.location)
+	if endDate.Before(startDate) || endDate.Sub(startDate) > time.Hour*24*maxDaysOffRange {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("end_date must be after start_date, and range must be less than or equal to %d days", maxDaysOffRange)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	daysOff, err := h.daysOffBetween(startDate, endDate)
+	if err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, err.Error()
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+	if daysOff == nil {
+		daysOff = []dayOff{}
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to get days off, days off num: %d", len(daysOff))
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "days_off": daysOff})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+}
+
+// set closure day of school in date of uri, only admin can set it
+func (h *_default) SetSchoolClosure(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.SetSchoolClosureRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code, msg := http.StatusForbidden, 0, "only admin can set closure day of school"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	date := c.Param("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		status, _code, msg := http.StatusBadRequest, code.IntegrityInvalidRequest, "date in uri must be formatted as 2006-01-02"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	if err := h.redisClient.HSet(ctx, schoolClosuresKey, date, receivedReq.Reason).Err(); err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to set closure day of school, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to set closure day of school, date: %s, reason: %s", date, receivedReq.Reason)
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+}
+
+// delete closure day of school in date of uri, only admin can delete it
+func (h *_default) DeleteSchoolClosure(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code, msg := http.StatusForbidden, 0, "only admin can delete closure day of school"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	deleted, err := h.redisClient.HDel(ctx, schoolClosuresKey, c.Param("date")).Result()
+	if err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to delete closure day of school, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+	if deleted == 0 {
+		status, _code, msg := http.StatusNotFound, 0, "closure day of school in that date is not found"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	status, _code, msg := http.StatusOK, 0, "succeed to delete closure day of school"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+}
@@ -19,7 +19,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/uber/jaeger-client-go"
 	"net/http"
-	"time"
 )
 
 func (h *_default) CreateOuting(c *gin.Context) {
@@ -43,7 +42,31 @@ func (h *_default) CreateOuting(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.CreateOutingRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.OutingServiceName)
+	// evaluate rules of outing policy before creating outing (add in v.1.0.5)
+	violatedRule, policyCounters, err := h.evaluateOutingPolicy(reqID, topSpan, uuidClaims.UUID, receivedReq)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("EvaluateOutingPolicy", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+	if violatedRule != nil {
+		status, _code := http.StatusForbidden, outingPolicyViolationCodes[violatedRule.Type]
+		msg := fmt.Sprintf("outing violates rule of outing policy, rule id: %s, description: %s", violatedRule.ID, violatedRule.Description)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "rule_id": violatedRule.ID})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	// quota counters increased in evaluation are decreased if outing is not created (add in v.1.0.5)
+	outingCreated := false
+	defer func() {
+		if !outingCreated {
+			h.decreaseOutingPolicyCounters(policyCounters)
+		}
+	}()
+
+	selectedNode, err := h.getNextServiceNode(c, topic.OutingServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -54,12 +77,12 @@ func (h *_default) CreateOuting(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *outingproto.CreateOutingResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.OutingServiceName, "CreateOuting", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		outingSrvSpan := h.tracer.StartSpan("CreateOuting", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -67,7 +90,9 @@ func (h *_default) CreateOuting(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.Uuid = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 		rpcResp, rpcErr = h.outingService.CreateOuting(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		outingSrvSpan.Finish()
 		return
@@ -92,11 +117,12 @@ func (h *_default) CreateOuting(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to CreateOuting service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("CreateOuting returns unexpected type of error, err: %s", rpcErr.Error())
@@ -114,6 +140,11 @@ func (h *_default) CreateOuting(c *gin.Context) {
 		c.JSON(status, sendResp)
 		respBytes, _ := json.Marshal(sendResp)
 		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+		h.publishOutingEvent(outingEvent{OutingUUID: rpcResp.OutingId, Action: "create", ActorUUID: uuidClaims.UUID, StudentUUID: uuidClaims.UUID}) // add in v.1.0.5
+		outingCreated = true // add in v.1.0.5
+		if receivedReq.Situation == "emergency" {
+			h.startEmergencyEscalation(rpcResp.OutingId, uuidClaims.UUID) // add in v.1.0.5
+		}
 	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusServiceUnavailable:
 		c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
 		entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "request": string(reqBytes)}).Error()
@@ -146,7 +177,7 @@ func (h *_default) GetStudentOutings(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetStudentOutingsRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.OutingServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.OutingServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -157,12 +188,12 @@ func (h *_default) GetStudentOutings(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *outingproto.GetStudentOutingsResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.OutingServiceName, "GetStudentOutings", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		outingSrvSpan := h.tracer.StartSpan("GetStudentOutings", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -171,7 +202,9 @@ func (h *_default) GetStudentOutings(c *gin.Context) {
 		rpcReq.Uuid = uuidClaims.UUID
 		rpcReq.StudentId = c.Param("student_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 		rpcResp, rpcErr = h.outingService.GetStudentOutings(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		outingSrvSpan.Finish()
 		return
@@ -196,11 +229,12 @@ func (h *_default) GetStudentOutings(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetStudentOutings service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetStudentOutings returns unexpected type of error, err: %s", rpcErr.Error())
@@ -257,7 +291,7 @@ func (h *_default) GetOutingInform(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.OutingServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.OutingServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -268,12 +302,12 @@ func (h *_default) GetOutingInform(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *outingproto.GetOutingInformResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.OutingServiceName, "GetOutingInform", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		outingSrvSpan := h.tracer.StartSpan("GetOutingInform", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -282,7 +316,9 @@ func (h *_default) GetOutingInform(c *gin.Context) {
 		rpcReq.Uuid = uuidClaims.UUID
 		rpcReq.OutingId = c.Param("outing_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 		rpcResp, rpcErr = h.outingService.GetOutingInform(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		outingSrvSpan.Finish()
 		return
@@ -307,11 +343,12 @@ func (h *_default) GetOutingInform(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetOutingInform service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetOutingInform returns unexpected type of error, err: %s", rpcErr.Error())
@@ -368,7 +405,7 @@ func (h *_default) GetCardAboutOuting(c *gin.Context) {
 	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
 	entry = entry.WithField("user_uuid", uuidClaims.UUID)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.OutingServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.OutingServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -379,12 +416,12 @@ func (h *_default) GetCardAboutOuting(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *outingproto.GetCardAboutOutingResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.OutingServiceName, "GetCardAboutOuting", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		outingSrvSpan := h.tracer.StartSpan("GetCardAboutOuting", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -393,7 +430,9 @@ func (h *_default) GetCardAboutOuting(c *gin.Context) {
 		rpcReq.Uuid = uuidClaims.UUID
 		rpcReq.OutingId = c.Param("outing_uuid")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 		rpcResp, rpcErr = h.outingService.GetCardAboutOuting(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		outingSrvSpan.Finish()
 		return
@@ -418,11 +457,12 @@ func (h *_default) GetCardAboutOuting(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetCardAboutOuting service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetCardAboutOuting returns unexpected type of error, err: %s", rpcErr.Error())
@@ -510,7 +550,28 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 		return
 	}
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.OutingServiceName)
+	// parent action is authorized with signed approval token issued in GetOutingByOCode, instead of bare confirm code (add in v.1.0.5)
+	var confirmCode string
+	if action := c.Param("action"); action == "parent-approve" || action == "parent-reject" {
+		approvalClaims, ocode, err := h.redeemParentApprovalToken(c.Param("outing_uuid"), c.Query("token"))
+		if err != nil {
+			status, _code, msg := http.StatusInternalServerError, 0, err.Error()
+			switch err {
+			case errInvalidApprovalToken:
+				status = http.StatusUnauthorized
+			case errUsedApprovalToken:
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+			return
+		}
+		confirmCode = ocode
+		uuidClaims.UUID = approvalClaims.ParentUUID
+		entry = entry.WithField("user_uuid", uuidClaims.UUID)
+	}
+
+	selectedNode, err := h.getNextServiceNode(c, topic.OutingServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -521,14 +582,14 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	switch c.Param("action") {
 	case "start", "end":
 		var rpcResp *outingproto.GoOutResponse
-		err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+		err = h.runInBulkhead(topic.OutingServiceName, methodName, h.breakers[selectedNode.Id], func() (rpcErr error) {
 			outingSrvSpan := h.tracer.StartSpan(methodName, opentracing.ChildOf(topSpan.Context()))
 			ctxForReq := context.Background()
 			ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -539,9 +600,13 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 			callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
 			switch c.Param("action") {
 			case "start":
+				rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 				rpcResp, rpcErr = h.outingService.StartGoOut(ctxForReq, rpcReq, callOpts...)
+				rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 			case "end":
+				rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 				rpcResp, rpcErr = h.outingService.FinishGoOut(ctxForReq, rpcReq, callOpts...)
+				rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 			}
 			outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 			outingSrvSpan.Finish()
@@ -567,11 +632,12 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 		default:
 			status, _code, msg := 0, 0, ""
 			switch rpcErr {
+			case ErrBulkheadFull:
+				status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+				msg = fmt.Sprintf("too many in-flight requests to %s service, please try again later", methodName)
 			case breaker.ErrBreakerOpen:
 				status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 				msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-				_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-				time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 			default:
 				status, _code = http.StatusInternalServerError, 0
 				msg = fmt.Sprintf("%s returns unexpected type of error, err: %s", methodName, rpcErr.Error())
@@ -589,6 +655,7 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 			c.JSON(status, sendResp)
 			respBytes, _ := json.Marshal(sendResp)
 			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes)}).Info()
+			h.publishOutingEvent(outingEvent{OutingUUID: c.Param("outing_uuid"), Action: c.Param("action"), ActorUUID: uuidClaims.UUID}) // add in v.1.0.5
 		case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusServiceUnavailable:
 			c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
 			entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg}).Error()
@@ -601,7 +668,7 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 
 	case "teacher-approve", "teacher-reject", "certify":
 		var rpcResp *outingproto.ConfirmOutingResponse
-		err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+		err = h.runInBulkhead(topic.OutingServiceName, methodName, h.breakers[selectedNode.Id], func() (rpcErr error) {
 			outingSrvSpan := h.tracer.StartSpan(methodName, opentracing.ChildOf(topSpan.Context()))
 			ctxForReq := context.Background()
 			ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -612,11 +679,17 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 			callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
 			switch c.Param("action") {
 			case "teacher-approve":
+				rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 				rpcResp, rpcErr = h.outingService.ApproveOuting(ctxForReq, rpcReq, callOpts...)
+				rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 			case "teacher-reject":
+				rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 				rpcResp, rpcErr = h.outingService.RejectOuting(ctxForReq, rpcReq, callOpts...)
+				rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 			case "certify":
+				rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 				rpcResp, rpcErr = h.outingService.CertifyOuting(ctxForReq, rpcReq, callOpts...)
+				rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 			}
 			outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 			outingSrvSpan.Finish()
@@ -642,11 +715,12 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 		default:
 			status, _code, msg := 0, 0, ""
 			switch rpcErr {
+			case ErrBulkheadFull:
+				status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+				msg = fmt.Sprintf("too many in-flight requests to %s service, please try again later", methodName)
 			case breaker.ErrBreakerOpen:
 				status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 				msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-				_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-				time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 			default:
 				status, _code = http.StatusInternalServerError, 0
 				msg = fmt.Sprintf("%s returns unexpected type of error, err: %s", methodName, rpcErr.Error())
@@ -664,6 +738,7 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 			c.JSON(status, sendResp)
 			respBytes, _ := json.Marshal(sendResp)
 			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes)}).Info()
+			h.publishOutingEvent(outingEvent{OutingUUID: c.Param("outing_uuid"), Action: c.Param("action"), ActorUUID: uuidClaims.UUID}) // add in v.1.0.5
 		case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusServiceUnavailable:
 			c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
 			entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg}).Error()
@@ -676,19 +751,23 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 
 	case "parent-approve", "parent-reject":
 		var rpcResp *outingproto.ConfirmOutingByOCodeResponse
-		err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+		err = h.runInBulkhead(topic.OutingServiceName, methodName, h.breakers[selectedNode.Id], func() (rpcErr error) {
 			outingSrvSpan := h.tracer.StartSpan(methodName, opentracing.ChildOf(topSpan.Context()))
 			ctxForReq := context.Background()
 			ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
 			ctxForReq = metadata.Set(ctxForReq, "Span-Context", outingSrvSpan.Context().(jaeger.SpanContext).String())
 			rpcReq := new(outingproto.ConfirmOutingByOCodeRequest)
-			rpcReq.ConfirmCode = c.Request.URL.Query().Get("code")
+			rpcReq.ConfirmCode = confirmCode // change in v.1.0.5
 			callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
 			switch c.Param("action") {
 			case "parent-approve":
+				rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 				rpcResp, rpcErr = h.outingService.ApproveOutingByOCode(ctxForReq, rpcReq, callOpts...)
+				rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 			case "parent-reject":
+				rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 				rpcResp, rpcErr = h.outingService.RejectOutingByOCode(ctxForReq, rpcReq, callOpts...)
+				rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 			}
 			outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 			outingSrvSpan.Finish()
@@ -714,11 +793,12 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 		default:
 			status, _code, msg := 0, 0, ""
 			switch rpcErr {
+			case ErrBulkheadFull:
+				status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+				msg = fmt.Sprintf("too many in-flight requests to %s service, please try again later", methodName)
 			case breaker.ErrBreakerOpen:
 				status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 				msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-				_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-				time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 			default:
 				status, _code = http.StatusInternalServerError, 0
 				msg = fmt.Sprintf("%s returns unexpected type of error, err: %s", methodName, rpcErr.Error())
@@ -736,6 +816,7 @@ func (h *_default) TakeActionInOuting(c *gin.Context) {
 			c.JSON(status, sendResp)
 			respBytes, _ := json.Marshal(sendResp)
 			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes)}).Info()
+			h.publishOutingEvent(outingEvent{OutingUUID: c.Param("outing_uuid"), Action: c.Param("action"), ActorUUID: uuidClaims.UUID}) // add in v.1.0.5
 		case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusServiceUnavailable:
 			c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
 			entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg}).Error()
@@ -769,7 +850,7 @@ func (h *_default) GetOutingWithFilter(c *gin.Context) {
 	receivedReq, _ := inAdvanceReq.(*entity.GetOutingWithFilterRequest)
 	reqBytes, _ := json.Marshal(receivedReq)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.OutingServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.OutingServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -780,12 +861,12 @@ func (h *_default) GetOutingWithFilter(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *outingproto.OutingResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.OutingServiceName, "GetOutingWithFilter", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		outingSrvSpan := h.tracer.StartSpan("GetOutingWithFilter", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -793,7 +874,9 @@ func (h *_default) GetOutingWithFilter(c *gin.Context) {
 		rpcReq := receivedReq.GenerateGRPCRequest()
 		rpcReq.Uuid = uuidClaims.UUID
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 		rpcResp, rpcErr = h.outingService.GetOutingWithFilter(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		outingSrvSpan.Finish()
 		return
@@ -818,11 +901,12 @@ func (h *_default) GetOutingWithFilter(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetOutingWithFilter service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetOutingWithFilter returns unexpected type of error, err: %s", rpcErr.Error())
@@ -879,7 +963,7 @@ func (h *_default) GetOutingByOCode(c *gin.Context) {
 	inAdvanceEntry, _ := c.Get("RequestLogEntry")
 	entry, _ := inAdvanceEntry.(*logrus.Entry)
 
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.OutingServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.OutingServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -890,12 +974,12 @@ func (h *_default) GetOutingByOCode(c *gin.Context) {
 
 	h.mutex.Lock()
 	if _, ok := h.breakers[selectedNode.Id]; !ok {
-		h.breakers[selectedNode.Id] = breaker.New(h.BreakerCfg.ErrorThreshold, h.BreakerCfg.SuccessThreshold, h.BreakerCfg.Timeout)
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
 	h.mutex.Unlock()
 
 	var rpcResp *outingproto.GetOutingByOCodeResponse
-	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
+	err = h.runInBulkhead(topic.OutingServiceName, "GetOutingByOCode", h.breakers[selectedNode.Id], func() (rpcErr error) {
 		outingSrvSpan := h.tracer.StartSpan("GetOutingByOCode", opentracing.ChildOf(topSpan.Context()))
 		ctxForReq := context.Background()
 		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
@@ -903,7 +987,9 @@ func (h *_default) GetOutingByOCode(c *gin.Context) {
 		rpcReq := new(outingproto.GetOutingByOCodeRequest)
 		rpcReq.ConfirmCode = c.Param("OCode")
 		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.OutingServiceName, selectedNode)
 		rpcResp, rpcErr = h.outingService.GetOutingByOCode(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
 		outingSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
 		outingSrvSpan.Finish()
 		return
@@ -928,11 +1014,12 @@ func (h *_default) GetOutingByOCode(c *gin.Context) {
 	default:
 		status, _code, msg := 0, 0, ""
 		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to GetOutingByOCode service, please try again later"
 		case breaker.ErrBreakerOpen:
 			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
 			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-			_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
-			time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
 		default:
 			status, _code = http.StatusInternalServerError, 0
 			msg = fmt.Sprintf("GetOutingByOCode returns unexpected type of error, err: %s", rpcErr.Error())
@@ -957,6 +1044,15 @@ func (h *_default) GetOutingByOCode(c *gin.Context) {
 			"end_time":         rpcResp.EndTime,
 			"outing_situation": rpcResp.Situation,
 		}
+		// add in v.1.0.5
+		token, expiresAt, err := h.issueParentApprovalToken(rpcResp.OutingId, c.Param("OCode"))
+		if err != nil {
+			status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to issue parent approval token, err: %v", err)
+			c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+			return
+		}
+		sendResp["approval_token"], sendResp["approval_token_expire_at"] = token, expiresAt
 		c.JSON(status, sendResp)
 		respBytes, _ := json.Marshal(sendResp)
 		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes)}).Info()
@@ -0,0 +1,150 @@
+// add file in v.1.0.5
+// default_outing_approval.go is file that declare issuing & redeeming signed parent approval token
+// token is issued in exchange for confirm code, and parent-approve & parent-reject actions are authorized only with it
+// only one token of outing is alive at a time, token issued again with confirm code revokes previous one
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	authproto "gateway/proto/golang/auth"
+	outingproto "gateway/proto/golang/outing"
+	topic "gateway/utils/topic/golang"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/mervick/aes-everywhere/go/aes256"
+	"github.com/micro/go-micro/v2/client"
+	"net/http"
+	"time"
+)
+
+var (
+	errInvalidApprovalToken = errors.New("parent approval token is invalid or expired")
+	errUsedApprovalToken    = errors.New("parent approval token was already used or revoked")
+)
+
+// delete issued key of outing only if it is of token redeemed now, so that token is redeemed once
+var redeemApprovalTokenScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// key holding id of alive parent approval token of outing, it expires with token and is overwritten on reissue
+func approvalTokenIssuedKey(outingUUID string) string {
+	return fmt.Sprintf("outings.%s.approval-token", outingUUID)
+}
+
+// parentApprovalClaims is claims of parent approval token, signed with HS256
+type parentApprovalClaims struct {
+	OutingUUID string `json:"outing_uuid"`
+	ParentUUID string `json:"parent_uuid"`
+	Code       string `json:"code"` // confirm code of outing encrypted with aes256
+	jwt.StandardClaims
+}
+
+// issue parent approval token of outing embedding confirm code, parent uuid is resolved with student of outing
+// if token of outing was issued and is not redeemed yet, it is rotated & previous token can't be redeemed any more
+func (h *_default) issueParentApprovalToken(outingUUID, confirmCode string) (token string, expiresAt int64, err error) {
+	parentUUID, err := h.resolveParentOfOuting(outingUUID)
+	if err != nil {
+		return
+	}
+	return h.signParentApprovalToken(outingUUID, parentUUID, confirmCode)
+}
+
+// sign parent approval token of outing for parent & mark it as alive token of outing in redis
+func (h *_default) signParentApprovalToken(outingUUID, parentUUID, confirmCode string) (token string, expiresAt int64, err error) {
+	now := time.Now()
+	expiresAt = now.Add(h.ApprovalTokenTTL).Unix()
+	claims := parentApprovalClaims{
+		OutingUUID: outingUUID,
+		ParentUUID: parentUUID,
+		Code:       aes256.Encrypt(confirmCode, string(h.approvalSecret)),
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt,
+		},
+	}
+
+	issuedKey := approvalTokenIssuedKey(outingUUID)
+	if setErr := h.redisClient.Set(ctx, issuedKey, claims.Id, h.ApprovalTokenTTL).Err(); setErr != nil {
+		err = errors.New(fmt.Sprintf("unable to mark parent approval token as issued, err: %v", setErr))
+		return
+	}
+
+	if token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(h.approvalSecret); err != nil {
+		h.redisClient.Del(ctx, issuedKey)
+	}
+	return
+}
+
+// check signature, expiry & parent of parent approval token for outing, and delete issued key of outing in redis
+// token is redeemed before calling outing service, so parent have to get new token if action fails
+func (h *_default) redeemParentApprovalToken(outingUUID, token string) (claims parentApprovalClaims, confirmCode string, err error) {
+	parsed, parseErr := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errInvalidApprovalToken
+		}
+		return h.approvalSecret, nil
+	})
+	if parseErr != nil || !parsed.Valid || claims.OutingUUID != outingUUID || claims.Id == "" || !parentUUIDRegex.MatchString(claims.ParentUUID) {
+		err = errInvalidApprovalToken
+		return
+	}
+	if confirmCode = aes256.Decrypt(claims.Code, string(h.approvalSecret)); confirmCode == "" {
+		err = errInvalidApprovalToken
+		return
+	}
+
+	redeemed, runErr := redeemApprovalTokenScript.Run(ctx, h.redisClient, []string{approvalTokenIssuedKey(outingUUID)}, claims.Id).Int()
+	if runErr != nil {
+		err = errors.New(fmt.Sprintf("unable to mark parent approval token as used, err: %v", runErr))
+	} else if redeemed != 1 {
+		err = errUsedApprovalToken
+	}
+	return
+}
+
+// return parent uuid of student in outing, student is got from outing service with worker uuid
+// error is returned if student or parent is not found, because token without parent can't be redeemed
+func (h *_default) resolveParentOfOuting(outingUUID string) (parentUUID string, err error) {
+	workerUUID := h.approvalWorkerUUID
+
+	var outingResp *outingproto.GetOutingInformResponse
+	err = h.callService(topic.OutingServiceName, "GetOutingInform", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		outingResp, rpcErr = h.outingService.GetOutingInform(ctx, &outingproto.GetOutingInformRequest{Uuid: workerUUID, OutingId: outingUUID}, opts...)
+		return int(outingResp.GetStatus()), rpcErr
+	})
+	if err == nil && outingResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetOutingInform responses with %d status, msg: %s", outingResp.Status, outingResp.Msg))
+	}
+	if err != nil {
+		return
+	}
+
+	var parentResp *authproto.GetParentWithStudentUUIDResponse
+	err = h.callService(topic.AuthServiceName, "GetParentWithStudentUUID", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcReq := &authproto.GetParentWithStudentUUIDRequest{UUID: workerUUID, StudentUUID: outingResp.StudentUuid}
+		parentResp, rpcErr = h.authService.GetParentWithStudentUUID(ctx, rpcReq, opts...)
+		return int(parentResp.GetStatus()), rpcErr
+	})
+	if err == nil && parentResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetParentWithStudentUUID responses with %d status, msg: %s", parentResp.Status, parentResp.Message))
+	}
+	if err == nil && parentResp.ParentUUID == "" {
+		err = errors.New(fmt.Sprintf("parent of student is not registered, student uuid: %s", outingResp.StudentUuid))
+	}
+	if err != nil {
+		return
+	}
+	parentUUID = parentResp.ParentUUID
+	return
+}
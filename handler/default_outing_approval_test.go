@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"gateway/tool/redistest"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestApprovalHandler() (*_default, *redistest.Server, func()) {
+	s := redistest.NewServer()
+	s.Script(redeemApprovalTokenScript.Hash(), func(s *redistest.Server, keys, args []string) interface{} {
+		if value, ok := s.Get(keys[0]); ok && value == args[0] {
+			s.Del(keys[0])
+			return 1
+		}
+		return 0
+	})
+
+	cli := redis.NewClient(&redis.Options{Addr: s.Addr})
+	h := &_default{redisClient: cli, approvalSecret: []byte("approval-secret"), ApprovalTokenTTL: time.Hour}
+	return h, s, func() {
+		_ = cli.Close()
+		s.Close()
+	}
+}
+
+func TestRedeemParentApprovalToken(t *testing.T) {
+	h, s, closeAll := newTestApprovalHandler()
+	defer closeAll()
+
+	token, expiresAt, err := h.signParentApprovalToken("outing-1", "parent-123412341234", "123456")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), time.Unix(expiresAt, 0), time.Second)
+	assert.InDelta(t, time.Hour, s.TTL(approvalTokenIssuedKey("outing-1")), float64(time.Second))
+
+	// token of other outing is invalid & not redeemed
+	_, _, err = h.redeemParentApprovalToken("outing-2", token)
+	assert.Equal(t, errInvalidApprovalToken, err)
+
+	claims, confirmCode, err := h.redeemParentApprovalToken("outing-1", token)
+	assert.NoError(t, err)
+	assert.Equal(t, "123456", confirmCode)
+	assert.Equal(t, "parent-123412341234", claims.ParentUUID)
+
+	// token can be redeemed only once
+	_, _, err = h.redeemParentApprovalToken("outing-1", token)
+	assert.Equal(t, errUsedApprovalToken, err)
+}
+
+func TestRotateParentApprovalToken(t *testing.T) {
+	h, _, closeAll := newTestApprovalHandler()
+	defer closeAll()
+
+	first, _, err := h.signParentApprovalToken("outing-1", "parent-123412341234", "123456")
+	assert.NoError(t, err)
+	second, _, err := h.signParentApprovalToken("outing-1", "parent-123412341234", "123456")
+	assert.NoError(t, err, "token is issued again if previous one is not redeemed")
+
+	// previous token is revoked by token issued again
+	_, _, err = h.redeemParentApprovalToken("outing-1", first)
+	assert.Equal(t, errUsedApprovalToken, err)
+	_, _, err = h.redeemParentApprovalToken("outing-1", second)
+	assert.NoError(t, err)
+}
+
+func TestRedeemInvalidParentApprovalToken(t *testing.T) {
+	h, s, closeAll := newTestApprovalHandler()
+	defer closeAll()
+
+	sign := func(claims parentApprovalClaims, secret string) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return token
+	}
+	valid := func() parentApprovalClaims {
+		return parentApprovalClaims{
+			OutingUUID: "outing-1",
+			ParentUUID: "parent-123412341234",
+			Code:       "",
+			StandardClaims: jwt.StandardClaims{
+				Id:        "token-1",
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			},
+		}
+	}
+
+	expired := valid()
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	withoutID := valid()
+	withoutID.Id = ""
+	withoutParent := valid()
+	withoutParent.ParentUUID = ""
+
+	for _, c := range []struct {
+		name  string
+		token string
+	}{
+		{"malformed token", "token"},
+		{"token signed with other secret", sign(valid(), "other-secret")},
+		{"expired token", sign(expired, "approval-secret")},
+		{"token without id", sign(withoutID, "approval-secret")},
+		{"token without parent", sign(withoutParent, "approval-secret")},
+		{"token without confirm code", sign(valid(), "approval-secret")},
+	} {
+		s.Set(approvalTokenIssuedKey("outing-1"), "token-1", time.Hour)
+		_, _, err := h.redeemParentApprovalToken("outing-1", c.token)
+		assert.Equal(t, errInvalidApprovalToken, err, c.name)
+		_, ok := s.Get(approvalTokenIssuedKey("outing-1"))
+		assert.True(t, ok, "%s, invalid token must not be redeemed", c.name)
+	}
+}
@@ -0,0 +1,121 @@
+// add file in v.1.0.5
+// default_outing_bulk.go is file that declare handler taking action in many outings at once, such as teacher-approve
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gateway/entity"
+	outingproto "gateway/proto/golang/outing"
+	jwtutil "gateway/tool/jwt"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+)
+
+// max number of outing actions dispatched to outing service at the same time in one bulk request
+const maxConcurrentOutingActions = 8
+
+// key of *gin.Context to set outing uuids in which action succeeded, used by redis handler to delete cache per outing
+const succeededItemsKey = "SucceededItems"
+
+// result of taking action in each outing of bulk request
+type outingActionResult struct {
+	OutingUUID string `json:"outing_uuid"`
+	Status     int    `json:"status"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+// take action (teacher-approve, teacher-reject, certify) in outings concurrently & response result per outing
+func (h *_default) TakeActionInOutings(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.TakeActionInOutingsRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	var methodName string
+	var method func(context.Context, *outingproto.ConfirmOutingRequest, ...client.CallOption) (*outingproto.ConfirmOutingResponse, error)
+	switch c.Param("action") {
+	case "teacher-approve":
+		methodName, method = "ApproveOuting", h.outingService.ApproveOuting
+	case "teacher-reject":
+		methodName, method = "RejectOuting", h.outingService.RejectOuting
+	case "certify":
+		methodName, method = "CertifyOuting", h.outingService.CertifyOuting
+	default:
+		msg := "that action in uri is not supported in bulk"
+		c.JSON(http.StatusNotFound, gin.H{"status": http.StatusNotFound, "code": 0, "message": msg})
+		entry.WithFields(logrus.Fields{"status": http.StatusNotFound, "code": 0, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	results := make([]outingActionResult, len(receivedReq.OutingUUIDs))
+	slots := make(chan struct{}, maxConcurrentOutingActions)
+	wg := sync.WaitGroup{}
+	for i, outingUUID := range receivedReq.OutingUUIDs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, outingUUID string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			var rpcResp *outingproto.ConfirmOutingResponse
+			err := h.callServiceInSpan(topic.OutingServiceName, methodName, reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+				var rpcErr error
+				rpcResp, rpcErr = method(ctx, &outingproto.ConfirmOutingRequest{Uuid: uuidClaims.UUID, OutingId: outingUUID}, opts...)
+				return int(rpcResp.GetStatus()), rpcErr
+			})
+
+			results[i] = outingActionResult{OutingUUID: outingUUID}
+			if err != nil {
+				results[i].Status, results[i].Code, results[i].Message = h.getStatusCodeFromCallErr(methodName, err)
+				return
+			}
+			results[i].Status, results[i].Code, results[i].Message = int(rpcResp.Status), int(rpcResp.Code), rpcResp.Msg
+			if rpcResp.Status == http.StatusOK {
+				results[i].Message = "succeed to take action to outing"
+			}
+		}(i, outingUUID)
+	}
+	wg.Wait()
+
+	var succeeded []string
+	for _, result := range results {
+		if result.Status == http.StatusOK {
+			succeeded = append(succeeded, result.OutingUUID)
+			h.publishOutingEvent(outingEvent{OutingUUID: result.OutingUUID, Action: c.Param("action"), ActorUUID: uuidClaims.UUID})
+		}
+	}
+	c.Set(succeededItemsKey, succeeded)
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to take action to outings in bulk, succeeded num: %d, failed num: %d", len(succeeded), len(results)-len(succeeded))
+	sendResp := gin.H{"status": status, "code": _code, "message": msg, "results": results}
+	c.JSON(status, sendResp)
+	respBytes, _ := json.Marshal(sendResp)
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+}
@@ -0,0 +1,286 @@
+// add file in v.1.0.5
+// default_outing_card.go is file that declare handler rendering outing card in signed QR code & verifying it
+// QR code is rendered & verified in gateway only, so that both work offline from third-party service
+
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/entity"
+	outingproto "gateway/proto/golang/outing"
+	jwtutil "gateway/tool/jwt"
+	"gateway/tool/qrcode"
+	code "gateway/utils/code/golang"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	outingCardPayloadPrefix  = "DMS-OUTING-CARD:v1"
+	outingCardEarlyAllowance = time.Minute * 10 // card is valid from 10 minutes before start time of outing
+	defaultQRCodeScale       = 8
+)
+
+var (
+	errMalformedOutingCard = errors.New("payload of outing card is malformed")
+	errInvalidCardSign     = errors.New("signature of outing card is not valid")
+)
+
+// outingCardPayload is data encoded in QR code of outing card, signed with HMAC-SHA256
+type outingCardPayload struct {
+	OutingUUID  string
+	StudentUUID string
+	NotBefore   int64
+	NotAfter    int64
+}
+
+// return payload in string format, "{prefix}|{outing uuid}|{student uuid}|{not before}|{not after}|{signature}"
+func (p outingCardPayload) sign(secret []byte) string {
+	unsigned := strings.Join([]string{outingCardPayloadPrefix, p.OutingUUID, p.StudentUUID,
+		strconv.FormatInt(p.NotBefore, 10), strconv.FormatInt(p.NotAfter, 10)}, "|")
+	return unsigned + "|" + outingCardSignature(secret, unsigned)
+}
+
+func outingCardSignature(secret []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parse payload in string format & check signature of it
+func parseOutingCardPayload(secret []byte, payload string) (p outingCardPayload, err error) {
+	sep := strings.LastIndex(payload, "|")
+	if sep < 0 {
+		err = errMalformedOutingCard
+		return
+	}
+	unsigned, signature := payload[:sep], payload[sep+1:]
+
+	fields := strings.Split(unsigned, "|")
+	if len(fields) != 5 || fields[0] != outingCardPayloadPrefix {
+		err = errMalformedOutingCard
+		return
+	}
+	p.OutingUUID, p.StudentUUID = fields[1], fields[2]
+	if p.NotBefore, err = strconv.ParseInt(fields[3], 10, 64); err != nil {
+		err = errMalformedOutingCard
+		return
+	}
+	if p.NotAfter, err = strconv.ParseInt(fields[4], 10, 64); err != nil {
+		err = errMalformedOutingCard
+		return
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(outingCardSignature(secret, unsigned))) {
+		err = errInvalidCardSign
+	}
+	return
+}
+
+// render card of outing in QR code (PNG or SVG) encoding signed payload, only student of outing can get it
+func (h *_default) GetOutingCardQRCode(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.GetOutingCardQRCodeRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	if !studentUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code := http.StatusForbidden, 0
+		msg := "only student can get QR code of outing card"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	rpcResp, err := h.getCardAboutOuting(reqID, topSpan, uuidClaims.UUID, c.Param("outing_uuid"))
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetCardAboutOuting", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+	if rpcResp.Status != http.StatusOK {
+		c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
+		entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	payload := outingCardPayload{
+		OutingUUID:  c.Param("outing_uuid"),
+		StudentUUID: uuidClaims.UUID,
+		NotBefore:   int64(rpcResp.StartTime) - int64(outingCardEarlyAllowance/time.Second),
+		NotAfter:    int64(rpcResp.EndTime),
+	}
+	qr, err := qrcode.Encode([]byte(payload.sign(h.outingCardSecret)))
+	if err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to encode outing card in QR code, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	scale := receivedReq.Scale
+	if scale == 0 {
+		scale = defaultQRCodeScale
+	}
+	var contentType string
+	var image []byte
+	switch receivedReq.Format {
+	case "svg":
+		contentType, image = "image/svg+xml", qr.SVG(scale)
+	default:
+		contentType = "image/png"
+		if image, err = qr.PNG(scale); err != nil {
+			status, _code := http.StatusInternalServerError, 0
+			msg := fmt.Sprintf("unable to render QR code of outing card in png, err: %v", err)
+			c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+			return
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, image)
+	msg := fmt.Sprintf("succeed to render outing card in QR code, version: %d", qr.Version)
+	entry.WithFields(logrus.Fields{"status": http.StatusOK, "code": 0, "message": msg, "request": string(reqBytes)}).Info()
+}
+
+// verify payload read from QR code of outing card by device of gate guard before certify
+// signature & validity window is checked in gateway, and outing is checked to be still same & certifiable in outing service
+func (h *_default) VerifyOutingCardQRCode(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.VerifyOutingCardQRCodeRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	payload, err := parseOutingCardPayload(h.outingCardSecret, receivedReq.Payload)
+	switch err {
+	case nil:
+		break
+	case errMalformedOutingCard:
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := err.Error()
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	default:
+		status, _code := http.StatusForbidden, 0
+		msg := err.Error()
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	if now := time.Now().Unix(); now < payload.NotBefore || now > payload.NotAfter {
+		status, _code := http.StatusForbidden, 0
+		msg := fmt.Sprintf("outing card is valid only from %s to %s", time.Unix(payload.NotBefore, 0).In(h.location).Format(time.RFC3339),
+			time.Unix(payload.NotAfter, 0).In(h.location).Format(time.RFC3339))
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	rpcResp, err := h.getCardAboutOuting(reqID, topSpan, uuidClaims.UUID, payload.OutingUUID)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetCardAboutOuting", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+	if rpcResp.Status != http.StatusOK {
+		c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
+		entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	// outing changed after QR code was rendered, or is not in status to certify
+	status, _code := http.StatusConflict, 0
+	switch {
+	case int64(rpcResp.StartTime)-int64(outingCardEarlyAllowance/time.Second) != payload.NotBefore || int64(rpcResp.EndTime) != payload.NotAfter:
+		msg := "outing card is outdated, time of outing was changed after QR code was rendered"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	case !h.certifiableOutingStatuses[rpcResp.OutingStatus]:
+		msg := fmt.Sprintf("outing in status %s can't be certified", rpcResp.OutingStatus)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	status, _code = http.StatusOK, 0
+	msg := "succeed to verify outing card, outing can be certified"
+	sendResp := gin.H{
+		"status":        status,
+		"code":          _code,
+		"message":       msg,
+		"outing_uuid":   payload.OutingUUID,
+		"student_uuid":  payload.StudentUUID,
+		"place":         rpcResp.Place,
+		"start_time":    rpcResp.StartTime,
+		"end_time":      rpcResp.EndTime,
+		"outing_status": rpcResp.OutingStatus,
+		"name":          rpcResp.Name,
+		"grade":         rpcResp.Grade,
+		"group":         rpcResp.Group,
+		"number":        rpcResp.Number,
+		"profile_uri":   rpcResp.ProfileImageUri,
+	}
+	c.JSON(status, sendResp)
+	respBytes, _ := json.Marshal(sendResp)
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+}
+
+// get card about outing from outing service with uuid of user requesting
+func (h *_default) getCardAboutOuting(reqID string, topSpan opentracing.Span, uuid, outingUUID string) (rpcResp *outingproto.GetCardAboutOutingResponse, err error) {
+	err = h.callServiceInSpan(topic.OutingServiceName, "GetCardAboutOuting", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.outingService.GetCardAboutOuting(ctx, &outingproto.GetCardAboutOutingRequest{Uuid: uuid, OutingId: outingUUID}, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	return
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseOutingCardPayload(t *testing.T) {
+	secret := []byte("secret")
+	payload := outingCardPayload{OutingUUID: "outing-1", StudentUUID: "student-1", NotBefore: 1600000000, NotAfter: 1600003600}
+	signed := payload.sign(secret)
+	unsigned := signed[:strings.LastIndex(signed, "|")]
+
+	for _, c := range []struct {
+		name    string
+		secret  []byte
+		payload string
+		err     error
+	}{
+		{"valid payload", secret, signed, nil},
+		{"another secret", []byte("another"), signed, errInvalidCardSign},
+		{"changed field", secret, strings.Replace(signed, "1600003600", "1600007200", 1), errInvalidCardSign},
+		{"without signature", secret, unsigned, errMalformedOutingCard},
+		{"without separator", secret, "DMS-OUTING-CARD:v1", errMalformedOutingCard},
+		{"unknown prefix", secret, strings.Replace(signed, outingCardPayloadPrefix, "DMS-OUTING-CARD:v2", 1), errMalformedOutingCard},
+		{"invalid not before", secret, "DMS-OUTING-CARD:v1|outing-1|student-1|now|1600003600|signature", errMalformedOutingCard},
+		{"invalid not after", secret, "DMS-OUTING-CARD:v1|outing-1|student-1|1600000000|later|signature", errMalformedOutingCard},
+	} {
+		parsed, err := parseOutingCardPayload(c.secret, c.payload)
+		assert.Equal(t, c.err, err, c.name)
+		if c.err == nil {
+			assert.Equal(t, payload, parsed, c.name)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+// add file in v.1.0.5
+// default_outing_emergency.go is file that declare escalation workflow of emergency outing
+// on-duty teachers & parent are notified when emergency outing is created, and admin is notified if no teacher acts in time
+// every notification & action is recorded in timeline of emergency outing, which can be got with status API
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/entity"
+	authproto "gateway/proto/golang/auth"
+	jwtutil "gateway/tool/jwt"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/micro/go-micro/v2/client"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	emergencyEscalationsKey = "outings.emergency.escalations"      // sorted set of outing uuid with escalation time as score
+	onDutyTeachersKey       = "outings.emergency.on_duty_teachers" // set of uuid of on-duty teachers
+	emergencyRetention      = time.Hour * 24 * 30                  // state & timeline of emergency outing are kept in this duration
+)
+
+// action resolving emergency outing, escalation is canceled if one of them is taken in outing
+var emergencyResolvingActions = map[string]string{
+	"teacher-approve": "approved",
+	"teacher-reject":  "rejected",
+	"parent-reject":   "rejected",
+}
+
+type EscalationConfig struct {
+	WorkerUUID    string            // uuid of admin used to call services in workflow, workflow doesn't run if empty
+	Timeout       time.Duration     // duration to wait action of teacher before escalating to admin
+	Interval      time.Duration     // interval to check emergency outings to escalate
+	AdminContacts map[string]string // phone number per uuid of admin notified in escalation
+}
+
+// emergencyTimelineEntry is entry in timeline of emergency outing
+type emergencyTimelineEntry struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"` // created, notified, notify_failed, acted, escalated
+	UserUUID string    `json:"user_uuid"`
+	Detail   string    `json:"detail"`
+}
+
+func emergencyStateKey(outingUUID string) string {
+	return fmt.Sprintf("outings.%s.emergency", outingUUID)
+}
+
+func emergencyTimelineKey(outingUUID string) string {
+	return fmt.Sprintf("outings.%s.emergency.timeline", outingUUID)
+}
+
+// start escalation workflow of emergency outing in another goroutine not to delay response
+// state of outing is saved & escalation is scheduled first, and then on-duty teachers & parent are notified
+func (h *_default) startEmergencyEscalation(outingUUID, studentUUID string) {
+	if h.EscalationCfg.WorkerUUID == "" {
+		log.Infof("skip to escalate emergency outing, because worker uuid is not set, outing uuid: %s", outingUUID)
+		return
+	}
+
+	go func() {
+		now := time.Now()
+		escalateAt := now.Add(h.EscalationCfg.Timeout)
+		stateKey := emergencyStateKey(outingUUID)
+		_, err := h.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, stateKey, "student_uuid", studentUUID, "status", "waiting",
+				"created_at", now.Unix(), "escalate_at", escalateAt.Unix())
+			pipe.Expire(ctx, stateKey, emergencyRetention)
+			pipe.ZAdd(ctx, emergencyEscalationsKey, &redis.Z{Score: float64(escalateAt.Unix()), Member: outingUUID})
+			return nil
+		})
+		if err != nil {
+			log.Errorf("unable to start escalation of emergency outing, outing uuid: %s, err: %v", outingUUID, err)
+			return
+		}
+		h.recordEmergencyTimeline(outingUUID, emergencyTimelineEntry{Event: "created", UserUUID: studentUUID})
+
+		msg := fmt.Sprintf("[DMS] %s 학생이 긴급 외출을 신청했습니다. 확인 후 승인 또는 거절해주세요.", h.describeStudent(studentUUID))
+		var notifications []Notification
+		teacherUUIDs, err := h.redisClient.SMembers(ctx, onDutyTeachersKey).Result()
+		if err != nil {
+			log.Errorf("unable to get on-duty teachers to notify emergency outing, err: %v", err)
+		}
+		for _, teacherUUID := range teacherUUIDs {
+			notification, err := h.teacherNotification(h.EscalationCfg.WorkerUUID, teacherUUID, "outing.emergency", msg)
+			if err != nil {
+				h.recordEmergencyTimeline(outingUUID, emergencyTimelineEntry{Event: "notify_failed", UserUUID: teacherUUID, Detail: err.Error()})
+				continue
+			}
+			notifications = append(notifications, notification)
+		}
+		if notification, err := h.parentNotification(h.EscalationCfg.WorkerUUID, studentUUID, "outing.emergency", msg); err != nil {
+			h.recordEmergencyTimeline(outingUUID, emergencyTimelineEntry{Event: "notify_failed", Detail: err.Error()})
+		} else {
+			notifications = append(notifications, notification)
+		}
+		h.notifyEmergency(outingUUID, notifications)
+	}()
+}
+
+// function that return closure starting escalator of emergency outings in another goroutine
+// each replica claims emergency outing to escalate by removing it from sorted set, so outing is escalated only once
+func (h *_default) EmergencyOutingEscalator() func() error {
+	return func() (_ error) {
+		if h.EscalationCfg.WorkerUUID == "" {
+			log.Info("emergency outing escalator is disabled, because worker uuid is not set")
+			return
+		}
+
+		go func() {
+			ticker := time.NewTicker(h.EscalationCfg.Interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := h.escalateEmergencyOutings(); err != nil {
+					log.Errorf("unable to escalate emergency outings, err: %v", err)
+				}
+			}
+		}()
+		log.Infof("start emergency outing escalator!! (timeout: %s, interval: %s)", h.EscalationCfg.Timeout, h.EscalationCfg.Interval)
+		return
+	}
+}
+
+// notify admins of emergency outings which are still waiting action of teacher after escalation time
+func (h *_default) escalateEmergencyOutings() (err error) {
+	outingUUIDs, err := h.redisClient.ZRangeByScore(ctx, emergencyEscalationsKey, &redis.ZRangeBy{
+		Min: "-inf", Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		err = errors.New(fmt.Sprintf("unable to get emergency outings to escalate, err: %v", err))
+		return
+	}
+
+	for _, outingUUID := range outingUUIDs {
+		if removed, err := h.redisClient.ZRem(ctx, emergencyEscalationsKey, outingUUID).Result(); err != nil || removed == 0 {
+			continue // claimed by another replica
+		}
+		state, err := h.redisClient.HGetAll(ctx, emergencyStateKey(outingUUID)).Result()
+		if err != nil || state["status"] != "waiting" {
+			continue
+		}
+		h.redisClient.HSet(ctx, emergencyStateKey(outingUUID), "status", "escalated")
+		h.recordEmergencyTimeline(outingUUID, emergencyTimelineEntry{Event: "escalated", Detail: fmt.Sprintf("no teacher acted in %s", h.EscalationCfg.Timeout)})
+
+		msg := fmt.Sprintf("[DMS] %s 학생의 긴급 외출이 %s 동안 처리되지 않았습니다. 확인해주세요. (outing: %s)",
+			h.describeStudent(state["student_uuid"]), h.EscalationCfg.Timeout, outingUUID)
+		var notifications []Notification
+		for adminUUID, phoneNumber := range h.EscalationCfg.AdminContacts {
+			notifications = append(notifications, Notification{Kind: "outing.emergency.escalated", ReceiverUUID: adminUUID, PhoneNumber: phoneNumber, Message: msg})
+		}
+		h.notifyEmergency(outingUUID, notifications)
+		log.Infof("emergency outing is escalated to admin!, outing uuid: %s", outingUUID)
+	}
+	return
+}
+
+// record action taken in emergency outing in timeline, and cancel escalation if action resolves outing
+// outing which is not emergency is ignored, because it doesn't have state of emergency outing
+func (h *_default) trackEmergencyOutingAction(outingUUID, action, actorUUID string) {
+	if h.EscalationCfg.WorkerUUID == "" || action == "create" {
+		return
+	}
+	if exist, err := h.redisClient.Exists(ctx, emergencyStateKey(outingUUID)).Result(); err != nil || exist == 0 {
+		return
+	}
+
+	h.recordEmergencyTimeline(outingUUID, emergencyTimelineEntry{Event: "acted", UserUUID: actorUUID, Detail: action})
+	if status, ok := emergencyResolvingActions[action]; ok {
+		h.redisClient.ZRem(ctx, emergencyEscalationsKey, outingUUID)
+		h.redisClient.HSet(ctx, emergencyStateKey(outingUUID), "status", status)
+	}
+}
+
+// send notifications of emergency outing & record result of each in timeline
+func (h *_default) notifyEmergency(outingUUID string, notifications []Notification) {
+	for _, notification := range notifications {
+		entry := emergencyTimelineEntry{Event: "notified", UserUUID: notification.ReceiverUUID, Detail: notification.Kind}
+		if err := h.notifier.Notify(notification); err != nil {
+			entry.Event, entry.Detail = "notify_failed", err.Error()
+			log.Errorf("unable to notify emergency outing, outing uuid: %s, err: %v", outingUUID, err)
+		}
+		h.recordEmergencyTimeline(outingUUID, entry)
+	}
+}
+
+// append entry in timeline of emergency outing
+func (h *_default) recordEmergencyTimeline(outingUUID string, entry emergencyTimelineEntry) {
+	entry.Time = time.Now()
+	entryBytes, _ := json.Marshal(entry)
+	key := emergencyTimelineKey(outingUUID)
+	if err := h.redisClient.RPush(ctx, key, string(entryBytes)).Err(); err != nil {
+		log.Errorf("unable to record timeline of emergency outing, outing uuid: %s, err: %v", outingUUID, err)
+		return
+	}
+	h.redisClient.Expire(ctx, key, emergencyRetention)
+}
+
+// return notification to teacher with phone number got from auth service
+func (h *_default) teacherNotification(workerUUID, teacherUUID, kind, msg string) (notification Notification, err error) {
+	var rpcResp *authproto.GetTeacherInformWithUUIDResponse
+	err = h.callService(topic.AuthServiceName, "GetTeacherInformWithUUID", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.authService.GetTeacherInformWithUUID(ctx, &authproto.GetTeacherInformWithUUIDRequest{UUID: workerUUID, TeacherUUID: teacherUUID}, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err == nil && rpcResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetTeacherInformWithUUID responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+	}
+	if err != nil {
+		return
+	}
+	notification = Notification{Kind: kind, ReceiverUUID: teacherUUID, PhoneNumber: rpcResp.PhoneNumber, Message: msg}
+	return
+}
+
+// return notification to parent of student with phone number got from auth service
+func (h *_default) parentNotification(workerUUID, studentUUID, kind, msg string) (notification Notification, err error) {
+	var rpcResp *authproto.GetParentWithStudentUUIDResponse
+	err = h.callService(topic.AuthServiceName, "GetParentWithStudentUUID", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.authService.GetParentWithStudentUUID(ctx, &authproto.GetParentWithStudentUUIDRequest{UUID: workerUUID, StudentUUID: studentUUID}, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err == nil && rpcResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetParentWithStudentUUID responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+	}
+	if err != nil {
+		return
+	}
+	notification = Notification{Kind: kind, ReceiverUUID: rpcResp.ParentUUID, PhoneNumber: rpcResp.PhoneNumber, Message: msg}
+	return
+}
+
+// return student number & name of student used in message, or student uuid if unable to get inform
+func (h *_default) describeStudent(studentUUID string) string {
+	var rpcResp *authproto.GetStudentInformWithUUIDResponse
+	err := h.callService(topic.AuthServiceName, "GetStudentInformWithUUID", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.authService.GetStudentInformWithUUID(ctx, &authproto.GetStudentInformWithUUIDRequest{UUID: h.EscalationCfg.WorkerUUID, StudentUUID: studentUUID}, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err != nil || rpcResp.Status != http.StatusOK {
+		return studentUUID
+	}
+	return fmt.Sprintf("%d%d%02d %s", rpcResp.Grade, rpcResp.Group, rpcResp.StudentNumber, rpcResp.Name)
+}
+
+// get state & timeline of emergency outing, only admin, teacher & student who created outing can get it
+func (h *_default) GetEmergencyOutingStatus(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	outingUUID := c.Param("outing_uuid")
+	state, err := h.redisClient.HGetAll(ctx, emergencyStateKey(outingUUID)).Result()
+	if err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to get state of emergency outing, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+	if len(state) == 0 {
+		status, _code, msg := http.StatusNotFound, 0, "emergency outing with that uuid is not found"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) && !teacherUUIDRegex.MatchString(uuidClaims.UUID) && state["student_uuid"] != uuidClaims.UUID {
+		status, _code, msg := http.StatusForbidden, 0, "you are not allowed to get status of that emergency outing"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	values, err := h.redisClient.LRange(ctx, emergencyTimelineKey(outingUUID), 0, -1).Result()
+	if err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to get timeline of emergency outing, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+	timeline := make([]emergencyTimelineEntry, 0, len(values))
+	for _, value := range values {
+		var timelineEntry emergencyTimelineEntry
+		if json.Unmarshal([]byte(value), &timelineEntry) == nil {
+			timeline = append(timeline, timelineEntry)
+		}
+	}
+
+	createdAt, _ := strconv.ParseInt(state["created_at"], 10, 64)
+	escalateAt, _ := strconv.ParseInt(state["escalate_at"], 10, 64)
+	status, _code := http.StatusOK, 0
+	msg := "succeed to get status of emergency outing"
+	sendResp := gin.H{
+		"status":             status,
+		"code":               _code,
+		"message":            msg,
+		"outing_uuid":        outingUUID,
+		"student_uuid":       state["student_uuid"],
+		"escalation_status":  state["status"],
+		"created_at":         createdAt,
+		"escalate_at":        escalateAt,
+		"emergency_timeline": timeline,
+	}
+	c.JSON(status, sendResp)
+	respBytes, _ := json.Marshal(sendResp)
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes)}).Info()
+}
+
+// replace on-duty teachers notified when emergency outing is created, only admin can set it
+func (h *_default) SetOnDutyTeachers(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.SetOnDutyTeachersRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code, msg := http.StatusForbidden, 0, "only admin can set on-duty teachers"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	_, err := h.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, onDutyTeachersKey)
+		for _, teacherUUID := range receivedReq.TeacherUUIDs {
+			pipe.SAdd(ctx, onDutyTeachersKey, teacherUUID)
+		}
+		return nil
+	})
+	if err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to set on-duty teachers, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to set on-duty teachers, count: %d", len(receivedReq.TeacherUUIDs))
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+}
@@ -0,0 +1,173 @@
+// add file in v.1.0.5
+// default_outing_overdue.go is file that declare background job detecting outings not returned after end time
+// job runs in only one replica of gateway elected as leader with redis key, and each overdue outing is notified once
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	authproto "gateway/proto/golang/auth"
+	outingproto "gateway/proto/golang/outing"
+	topic "gateway/utils/topic/golang"
+	"github.com/go-redis/redis/v8"
+	"github.com/micro/go-micro/v2/client"
+	log "github.com/micro/go-micro/v2/logger"
+	"net/http"
+	"time"
+)
+
+const (
+	overdueLeaderKey   = "outings.overdue.leader"
+	overdueCursorKey   = "outings.overdue.cursor" // offset of outings to resume scanning in next detection
+	overdueNotifiedTTL = time.Hour * 24 * 7       // outing is notified once in this duration
+)
+
+// renew TTL of leader key only if this replica is leader
+var renewLeaderScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type OverdueConfig struct {
+	WorkerUUID string        // uuid of admin used to call services in job, job doesn't run if empty
+	OutStatus  string        // status of outing in which student went out & didn't return yet
+	Interval   time.Duration // interval to detect overdue outings
+	Grace      time.Duration // duration allowed after end time before outing is overdue
+}
+
+// function that return closure starting overdue outing detector in another goroutine
+func (h *_default) OverdueOutingDetector() func() error {
+	return func() (_ error) {
+		if h.OverdueCfg.WorkerUUID == "" {
+			log.Info("overdue outing detector is disabled, because worker uuid is not set")
+			return
+		}
+
+		go func() {
+			ticker := time.NewTicker(h.OverdueCfg.Interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if !h.isOverdueLeader() {
+					continue
+				}
+				if err := h.detectOverdueOutings(); err != nil {
+					log.Errorf("unable to detect overdue outings, err: %v", err)
+				}
+			}
+		}()
+		log.Infof("start overdue outing detector!! (replica: %s, interval: %s)", h.replicaID, h.OverdueCfg.Interval)
+		return
+	}
+}
+
+// try to be leader of overdue outing detector, or renew TTL of leader key if already leader
+// leader key expires after two intervals, so that another replica becomes leader if leader is dead
+func (h *_default) isOverdueLeader() bool {
+	ttl := h.OverdueCfg.Interval * 2
+	if ok, err := h.redisClient.SetNX(ctx, overdueLeaderKey, h.replicaID, ttl).Result(); err != nil || ok {
+		return err == nil
+	}
+	renewed, err := renewLeaderScript.Run(ctx, h.redisClient, []string{overdueLeaderKey}, h.replicaID, ttl.Milliseconds()).Int()
+	return err == nil && renewed == 1
+}
+
+// find outings in out status passing end time with grace, and publish overdue event & notify teacher & parent once
+// at most maxScanOutings outings are scanned at once, and scanning is resumed from cursor kept in redis in next detection
+func (h *_default) detectOverdueOutings() (err error) {
+	now := time.Now()
+	filter := func(start, count int32) *outingproto.GetOutingWithFilterRequest {
+		return &outingproto.GetOutingWithFilterRequest{Start: start, Count: count, Status: h.OverdueCfg.OutStatus}
+	}
+	cursor, cursorErr := h.redisClient.Get(ctx, overdueCursorKey).Int()
+	if cursorErr != nil && cursorErr != redis.Nil {
+		log.Errorf("unable to get cursor of overdue outings, scan from first outing, err: %v", cursorErr)
+	}
+	outings, next, rpcResp, err := h.scanOutingsFrom("", nil, h.OverdueCfg.WorkerUUID, filter, 0, now.Unix(), int32(cursor))
+	if err == nil && rpcResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetOutingWithFilter responses with %d status, msg: %s", rpcResp.Status, rpcResp.Msg))
+	}
+	if err != nil {
+		return
+	}
+
+	// outings scanned until now are checked, rest of them are checked from next in next detection
+	// scanning starts over from first outing in next detection if last page was scanned
+	if next != 0 {
+		log.Errorf("outings in out status are more than %d, outings from %d are checked in next detection", maxScanOutings, next)
+		if err := h.redisClient.Set(ctx, overdueCursorKey, next, overdueNotifiedTTL).Err(); err != nil {
+			log.Errorf("unable to set cursor of overdue outings, err: %v", err)
+		}
+	} else if cursor != 0 {
+		h.redisClient.Del(ctx, overdueCursorKey)
+	}
+
+	for _, outing := range outings {
+		if now.Before(time.Unix(outing.endTime, 0).Add(h.OverdueCfg.Grace)) {
+			continue
+		}
+		// notified key is set in advance so that outing is not notified twice, and deleted if no one is notified
+		notifiedKey := fmt.Sprintf("outings.%s.overdue_notified", outing.outingUUID)
+		if ok, err := h.redisClient.SetNX(ctx, notifiedKey, now.Unix(), overdueNotifiedTTL).Result(); err != nil || !ok {
+			continue
+		}
+
+		notified := 0
+		for _, notification := range h.overdueNotifications(outing) {
+			if err := h.notifier.Notify(notification); err != nil {
+				log.Errorf("unable to notify overdue outing, outing uuid: %s, err: %v", outing.outingUUID, err)
+				continue
+			}
+			notified++
+		}
+		if notified == 0 {
+			// outing is detected again & notified in next detection
+			if err := h.redisClient.Del(ctx, notifiedKey).Err(); err != nil {
+				log.Errorf("unable to delete overdue notified key, outing uuid: %s, err: %v", outing.outingUUID, err)
+			}
+			log.Warnf("no one is notified of overdue outing, outing uuid: %s", outing.outingUUID)
+			continue
+		}
+
+		h.publishOutingEvent(outingEvent{OutingUUID: outing.outingUUID, Action: "overdue", StudentUUID: outing.studentUUID})
+		log.Infof("overdue outing is detected!, outing uuid: %s, student uuid: %s, notified: %d", outing.outingUUID, outing.studentUUID, notified)
+	}
+	return
+}
+
+// return notifications of overdue outing to homeroom teachers of group & parent of student
+func (h *_default) overdueNotifications(outing scannedOuting) (notifications []Notification) {
+	msg := fmt.Sprintf("[DMS] %d%d%02d %s 학생이 외출 복귀 시간(%s)이 지났지만 아직 복귀하지 않았습니다.",
+		outing.grade, outing.group, outing.number, outing.name, time.Unix(outing.endTime, 0).In(h.location).Format("15:04"))
+	workerUUID := h.OverdueCfg.WorkerUUID
+
+	var teachersResp *authproto.GetTeacherUUIDsWithInformResponse
+	err := h.callService(topic.AuthServiceName, "GetTeacherUUIDsWithInform", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		rpcReq := &authproto.GetTeacherUUIDsWithInformRequest{UUID: workerUUID, Grade: uint32(outing.grade), Group: uint32(outing.group)}
+		var rpcErr error
+		teachersResp, rpcErr = h.authService.GetTeacherUUIDsWithInform(ctx, rpcReq, opts...)
+		return int(teachersResp.GetStatus()), rpcErr
+	})
+	if err == nil && teachersResp.Status == http.StatusOK {
+		for _, teacherUUID := range teachersResp.TeacherUUIDs {
+			notification, err := h.teacherNotification(workerUUID, teacherUUID, "outing.overdue", msg)
+			if err != nil {
+				log.Errorf("unable to get teacher inform to notify overdue outing, teacher uuid: %s, err: %v", teacherUUID, err)
+				continue
+			}
+			notifications = append(notifications, notification)
+		}
+	} else {
+		log.Errorf("unable to get homeroom teachers to notify overdue outing, outing uuid: %s, err: %v", outing.outingUUID, err)
+	}
+
+	if notification, err := h.parentNotification(workerUUID, outing.studentUUID, "outing.overdue", msg); err == nil {
+		notifications = append(notifications, notification)
+	} else {
+		log.Errorf("unable to get parent to notify overdue outing, outing uuid: %s, err: %v", outing.outingUUID, err)
+	}
+	return
+}
@@ -0,0 +1,310 @@
+// add file in v.1.0.5
+// default_outing_policy.go is file that declare policy engine evaluating rules of school when student creates outing
+// rules are loaded from consul KV or file & reloaded periodically, so that rules can be changed without deployment
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/consul"
+	"gateway/entity"
+	authproto "gateway/proto/golang/auth"
+	topic "gateway/utils/topic/golang"
+	"github.com/go-playground/validator/v10"
+	"github.com/micro/go-micro/v2/client"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/opentracing/opentracing-go"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// response code returned per type of violated rule
+var outingPolicyViolationCodes = map[string]int{
+	"time_window":     outingPolicyTimeWindowCode,
+	"quota":           outingPolicyQuotaCode,
+	"parent_approval": outingPolicyParentApprovalCode,
+}
+
+// OutingPolicySource is function loading outing policy, such as consul KV or file
+type OutingPolicySource func() (consul.OutingPolicyKV, error)
+
+// return source loading outing policy from consul KV with agent
+func OutingPolicyFromKV(agent consul.Agent, key string) OutingPolicySource {
+	return func() (consul.OutingPolicyKV, error) {
+		return agent.GetOutingPolicyFromKV(key)
+	}
+}
+
+// return source loading outing policy from JSON or YAML file
+func OutingPolicyFromFile(path string) OutingPolicySource {
+	validate := validator.New()
+
+	return func() (policy consul.OutingPolicyKV, err error) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			err = errors.New(fmt.Sprintf("unable to read outing policy file, err: %v", err))
+			return
+		}
+
+		switch filepath.Ext(path) {
+		case ".json":
+			err = json.Unmarshal(content, &policy)
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(content, &policy)
+		default:
+			err = errors.New(fmt.Sprintf("unsupported extension of outing policy file, path: %s", path))
+		}
+		if err != nil {
+			err = errors.New(fmt.Sprintf("unable to unmarshal outing policy file, err: %v", err))
+			return
+		}
+
+		if err = validate.Struct(policy); err != nil {
+			err = errors.New(fmt.Sprintf("invalid outing policy file, err: %v", err))
+		}
+		return
+	}
+}
+
+// function that return closure loading outing policy & starting reloader of policy in another goroutine
+// error is returned if policy can't be loaded at first, but previous policy is kept if reloading fails
+func (h *_default) OutingPolicyLoader() func() error {
+	return func() (err error) {
+		if h.policySource == nil {
+			log.Info("outing policy is disabled, because source of policy is not set")
+			return
+		}
+
+		if err = h.reloadOutingPolicy(); err != nil {
+			return
+		}
+		if h.PolicyReloadInterval == 0 {
+			return
+		}
+
+		go func() {
+			ticker := time.NewTicker(h.PolicyReloadInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := h.reloadOutingPolicy(); err != nil {
+					log.Errorf("unable to reload outing policy, policy is not changed, err: %v", err)
+				}
+			}
+		}()
+		log.Infof("start outing policy reloader!! (interval: %s)", h.PolicyReloadInterval)
+		return
+	}
+}
+
+// load outing policy from source & replace rules in use
+func (h *_default) reloadOutingPolicy() (err error) {
+	policy, err := h.policySource()
+	if err != nil {
+		return
+	}
+
+	h.policyMutex.Lock()
+	h.outingPolicy = policy
+	h.policyMutex.Unlock()
+	return
+}
+
+// outingPolicyCounter is redis counter of quota rule applied to outing
+// counter is increased while evaluating policy, so that concurrent outings can't pass quota, and decreased if outing isn't created
+type outingPolicyCounter struct {
+	key      string
+	expireAt time.Time
+}
+
+// evaluate rules of outing policy in order & return first rule violated by outing, or nil if outing follows all rules
+// counters of quota rules applied to outing are increased & returned, to decrease them if outing is not created
+// increased counters are decreased in here if rule is violated or error occurs
+func (h *_default) evaluateOutingPolicy(reqID string, topSpan opentracing.Span, studentUUID string, outing *entity.CreateOutingRequest) (
+	violated *consul.OutingPolicyRule, counters []outingPolicyCounter, err error) {
+	defer func() {
+		if violated != nil || err != nil {
+			h.decreaseOutingPolicyCounters(counters)
+			counters = nil
+		}
+	}()
+
+	h.policyMutex.RLock()
+	rules := h.outingPolicy.Rules
+	h.policyMutex.RUnlock()
+
+	var student *authproto.GetStudentInformWithUUIDResponse
+	startTime := time.Unix(outing.StartTime.Unix(), 0).In(h.location)
+	endTime := time.Unix(outing.EndTime.Unix(), 0).In(h.location)
+
+	for index := range rules {
+		rule := &rules[index]
+		if !containsString(rule.Situations, outing.Situation, true) || containsString(rule.ExemptSituations, outing.Situation, false) {
+			continue
+		}
+
+		// get grade & group of student only if there is rule with grade or group condition
+		if (len(rule.Grades) != 0 || len(rule.Groups) != 0) && student == nil {
+			if student, err = h.getStudentInformForPolicy(reqID, topSpan, studentUUID); err != nil {
+				return
+			}
+		}
+		if student != nil && (!containsInt(rule.Grades, int(student.Grade)) || !containsInt(rule.Groups, int(student.Group))) {
+			continue
+		}
+
+		// check if start date of outing is day off only if there is rule with days condition
+		if rule.Days != "" {
+			off, offErr := h.dayOffOf(startTime)
+			if offErr != nil {
+				err = offErr
+				return
+			}
+			if (rule.Days == "days_off") != (off != nil) {
+				continue
+			}
+		}
+
+		switch rule.Type {
+		case "time_window":
+			if overlapsTimeWindow(startTime, endTime, rule.Weekdays, rule.From, rule.To) {
+				violated = rule
+				return
+			}
+		case "quota":
+			counter := quotaCounter(rule, studentUUID, startTime)
+			count, incrErr := h.redisClient.Incr(ctx, counter.key).Result()
+			if incrErr != nil {
+				err = errors.New(fmt.Sprintf("unable to increase quota counter of outing policy, key: %s, err: %v", counter.key, incrErr))
+				return
+			}
+			h.redisClient.ExpireAt(ctx, counter.key, counter.expireAt)
+			counters = append(counters, counter)
+			if rule.MaxOutings != nil && count > int64(*rule.MaxOutings) {
+				violated = rule
+				return
+			}
+		case "parent_approval":
+			var rpcResp *authproto.GetParentWithStudentUUIDResponse
+			err = h.callServiceInSpan(topic.AuthServiceName, "GetParentWithStudentUUID", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+				var rpcErr error
+				rpcResp, rpcErr = h.authService.GetParentWithStudentUUID(ctx, &authproto.GetParentWithStudentUUIDRequest{UUID: studentUUID, StudentUUID: studentUUID}, opts...)
+				return int(rpcResp.GetStatus()), rpcErr
+			})
+			if err != nil {
+				return
+			}
+			switch rpcResp.Status {
+			case http.StatusOK:
+			case http.StatusNotFound:
+				violated = rule
+				return
+			default:
+				err = errors.New(fmt.Sprintf("GetParentWithStudentUUID responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+				return
+			}
+		}
+	}
+	return
+}
+
+// decrease quota counters of outing policy increased in evaluation, if outing is not created
+func (h *_default) decreaseOutingPolicyCounters(counters []outingPolicyCounter) {
+	for _, counter := range counters {
+		if err := h.redisClient.Decr(ctx, counter.key).Err(); err != nil {
+			log.Errorf("unable to decrease quota counter of outing policy, key: %s, err: %v", counter.key, err)
+		}
+	}
+}
+
+// get grade & group of student to check conditions of rule
+func (h *_default) getStudentInformForPolicy(reqID string, topSpan opentracing.Span, studentUUID string) (rpcResp *authproto.GetStudentInformWithUUIDResponse, err error) {
+	err = h.callServiceInSpan(topic.AuthServiceName, "GetStudentInformWithUUID", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.authService.GetStudentInformWithUUID(ctx, &authproto.GetStudentInformWithUUIDRequest{UUID: studentUUID, StudentUUID: studentUUID}, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err == nil && rpcResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetStudentInformWithUUID responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+	}
+	return
+}
+
+// return redis counter of quota rule for student in period including start time of outing
+// counter expires a day after period ends, so that counter can be read while period
+func quotaCounter(rule *consul.OutingPolicyRule, studentUUID string, startTime time.Time) (counter outingPolicyCounter) {
+	day := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
+	var period string
+	var periodEnd time.Time
+
+	switch rule.Period {
+	case "day":
+		period, periodEnd = day.Format("2006-01-02"), day.AddDate(0, 0, 1)
+	case "week":
+		year, week := day.ISOWeek()
+		offset := (int(day.Weekday()) + 6) % 7 // days since monday
+		period, periodEnd = fmt.Sprintf("%d-W%02d", year, week), day.AddDate(0, 0, 7-offset)
+	case "month":
+		period, periodEnd = day.Format("2006-01"), day.AddDate(0, 1, 1-day.Day())
+	}
+
+	counter.key = fmt.Sprintf("outings.policy.%s.students.%s.%s", rule.ID, studentUUID, period)
+	counter.expireAt = periodEnd.Add(time.Hour * 24)
+	return
+}
+
+// check if period start ~ end overlaps time window from ~ to starting on one of weekdays (all if empty)
+// window passes midnight if from is after to, so window starting on the day before start is checked too
+func overlapsTimeWindow(start, end time.Time, weekdays []string, from, to string) bool {
+	fromClock, fromErr := time.Parse("15:04", from)
+	toClock, toErr := time.Parse("15:04", to)
+	if fromErr != nil || toErr != nil || fromClock.Equal(toClock) {
+		return false // window is empty if from is same as to
+	}
+
+	for day := time.Date(start.Year(), start.Month(), start.Day()-1, 0, 0, 0, 0, start.Location()); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !containsString(weekdays, day.Weekday().String(), true) {
+			continue
+		}
+		windowStart := time.Date(day.Year(), day.Month(), day.Day(), fromClock.Hour(), fromClock.Minute(), 0, 0, day.Location())
+		windowEnd := time.Date(day.Year(), day.Month(), day.Day(), toClock.Hour(), toClock.Minute(), 0, 0, day.Location())
+		if windowEnd.Before(windowStart) {
+			windowEnd = windowEnd.AddDate(0, 0, 1)
+		}
+		if windowStart.Before(end) && start.Before(windowEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// check if slice contains value, or return ifEmpty if slice is empty
+func containsString(slice []string, value string, ifEmpty bool) bool {
+	if len(slice) == 0 {
+		return ifEmpty
+	}
+	for _, elem := range slice {
+		if elem == value {
+			return true
+		}
+	}
+	return false
+}
+
+// check if slice contains value, or return true if slice is empty
+func containsInt(slice []int, value int) bool {
+	if len(slice) == 0 {
+		return true
+	}
+	for _, elem := range slice {
+		if elem == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"gateway/consul"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testLocation = time.FixedZone("KST", 9*60*60)
+
+func TestQuotaCounter(t *testing.T) {
+	start := time.Date(2020, 12, 30, 15, 0, 0, 0, testLocation) // Wednesday
+	for _, c := range []struct {
+		period   string
+		key      string
+		expireAt time.Time
+	}{
+		{"day", "outings.policy.rule.students.student-1.2020-12-30", time.Date(2020, 12, 31, 0, 0, 0, 0, testLocation).Add(time.Hour * 24)},
+		{"week", "outings.policy.rule.students.student-1.2020-W53", time.Date(2021, 1, 4, 0, 0, 0, 0, testLocation).Add(time.Hour * 24)},
+		{"month", "outings.policy.rule.students.student-1.2020-12", time.Date(2021, 1, 1, 0, 0, 0, 0, testLocation).Add(time.Hour * 24)},
+	} {
+		counter := quotaCounter(&consul.OutingPolicyRule{ID: "rule", Period: c.period}, "student-1", start)
+		assert.Equal(t, c.key, counter.key, c.period)
+		assert.True(t, c.expireAt.Equal(counter.expireAt), "expire time of %s, expected: %s, actual: %s", c.period, c.expireAt, counter.expireAt)
+	}
+
+	// week period ends on next monday even if outing starts on sunday
+	sunday := time.Date(2021, 1, 3, 15, 0, 0, 0, testLocation)
+	counter := quotaCounter(&consul.OutingPolicyRule{ID: "rule", Period: "week"}, "student-1", sunday)
+	assert.Equal(t, "outings.policy.rule.students.student-1.2020-W53", counter.key)
+	assert.True(t, time.Date(2021, 1, 5, 0, 0, 0, 0, testLocation).Equal(counter.expireAt))
+}
+
+func TestOverlapsTimeWindow(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2020, 9, day, hour, minute, 0, 0, testLocation) // 2020-09-07 is Monday
+	}
+
+	for _, c := range []struct {
+		name       string
+		start, end time.Time
+		weekdays   []string
+		from, to   string
+		expected   bool
+	}{
+		{"before window", at(7, 10, 0), at(7, 12, 0), nil, "13:00", "15:00", false},
+		{"end at start of window", at(7, 10, 0), at(7, 13, 0), nil, "13:00", "15:00", false},
+		{"end in window", at(7, 12, 0), at(7, 14, 0), nil, "13:00", "15:00", true},
+		{"start in window", at(7, 14, 0), at(7, 16, 0), nil, "13:00", "15:00", true},
+		{"start at end of window", at(7, 15, 0), at(7, 16, 0), nil, "13:00", "15:00", false},
+		{"cover whole window", at(7, 12, 0), at(7, 16, 0), nil, "13:00", "15:00", true},
+		{"cover window of next day", at(7, 16, 0), at(8, 14, 0), nil, "13:00", "15:00", true},
+		{"window passing midnight started in previous day", at(8, 5, 0), at(8, 7, 0), nil, "21:00", "06:00", true},
+		{"window passing midnight", at(7, 20, 0), at(7, 21, 30), nil, "21:00", "06:00", true},
+		{"out of window passing midnight", at(7, 6, 0), at(7, 21, 0), nil, "21:00", "06:00", false},
+		{"window on other weekday", at(7, 12, 0), at(7, 14, 0), []string{"Tuesday"}, "13:00", "15:00", false},
+		{"window on weekday", at(8, 12, 0), at(8, 14, 0), []string{"Tuesday"}, "13:00", "15:00", true},
+		{"window passing midnight started on weekday", at(8, 1, 0), at(8, 2, 0), []string{"Monday"}, "21:00", "06:00", true},
+		{"window passing midnight started on other weekday", at(8, 1, 0), at(8, 2, 0), []string{"Tuesday"}, "21:00", "06:00", false},
+		{"empty window", at(7, 0, 0), at(8, 0, 0), nil, "13:00", "13:00", false},
+		{"invalid window", at(7, 12, 0), at(7, 14, 0), nil, "1300", "15:00", false},
+	} {
+		assert.Equal(t, c.expected, overlapsTimeWindow(c.start, c.end, c.weekdays, c.from, c.to), c.name)
+	}
+}
@@ -0,0 +1,75 @@
+// add file in v.1.0.5
+// default_outing_scan.go is file that declare method paging through outings with filter, used by export & statistics
+
+package handler
+
+import (
+	"context"
+	outingproto "gateway/proto/golang/outing"
+	topic "gateway/utils/topic/golang"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"net/http"
+)
+
+const (
+	scanPageSize   = 100   // number of outings got from outing service at once
+	maxScanOutings = 10000 // max number of outings scanned at once
+)
+
+// scannedOuting is outing copied from outing in response of GetOutingWithFilter
+type scannedOuting struct {
+	outingUUID, studentUUID string
+	name, place, reason     string
+	startTime, endTime      int64
+	status, situation       interface{}
+	grade, group, number    int
+	isLate                  bool
+}
+
+// page through outings with filter & gather outings which start in [startTime, endTime)
+// filter return request of GetOutingWithFilter with start & count, and rpcResp is last response to be checked by caller
+// truncated is true if scanning stopped at maxScanOutings before last page, so that outings are not complete
+func (h *_default) scanOutings(reqID string, topSpan opentracing.Span, uuid string, filter func(start, count int32) *outingproto.GetOutingWithFilterRequest,
+	startTime, endTime int64) (outings []scannedOuting, truncated bool, rpcResp *outingproto.OutingResponse, err error) {
+	var next int32
+	outings, next, rpcResp, err = h.scanOutingsFrom(reqID, topSpan, uuid, filter, startTime, endTime, 0)
+	truncated = next != 0
+	return
+}
+
+// page through at most maxScanOutings outings from offset, next is offset to resume scanning or 0 if last page is scanned
+func (h *_default) scanOutingsFrom(reqID string, topSpan opentracing.Span, uuid string, filter func(start, count int32) *outingproto.GetOutingWithFilterRequest,
+	startTime, endTime int64, from int32) (outings []scannedOuting, next int32, rpcResp *outingproto.OutingResponse, err error) {
+	start := from
+	for ; start < from+maxScanOutings; start += scanPageSize {
+		err = h.callServiceInSpan(topic.OutingServiceName, "GetOutingWithFilter", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			rpcReq := filter(start, scanPageSize)
+			rpcReq.Uuid = uuid
+			var rpcErr error
+			rpcResp, rpcErr = h.outingService.GetOutingWithFilter(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		if err != nil || rpcResp.Status != http.StatusOK {
+			return
+		}
+
+		for _, outing := range rpcResp.Outing {
+			if int64(outing.StartTime) < startTime || int64(outing.StartTime) >= endTime {
+				continue
+			}
+			outings = append(outings, scannedOuting{
+				outingUUID: outing.OutingId, studentUUID: outing.StudentUuid, name: outing.Name, place: outing.Place, reason: outing.Reason,
+				startTime: int64(outing.StartTime), endTime: int64(outing.EndTime),
+				status: outing.Status, situation: outing.Situation,
+				grade: int(outing.Grade), group: int(outing.Group), number: int(outing.Number),
+				isLate: outing.IsLate,
+			})
+		}
+		if len(rpcResp.Outing) < scanPageSize {
+			return
+		}
+	}
+	next = start
+	return
+}
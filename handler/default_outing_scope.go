@@ -0,0 +1,230 @@
+// add file in v.1.0.5
+// default_outing_scope.go is file that declare scope of outings which user can get with filter
+// filter of homeroom teacher is narrowed to own class, and admin can set exception of teacher such as dormitory supervisor
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/entity"
+	authproto "gateway/proto/golang/auth"
+	jwtutil "gateway/tool/jwt"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// hash of scope exception per teacher uuid, scope is one of all (every outing) & grade (every class in own grade)
+const outingScopeExceptionsKey = "outings.scope.exceptions"
+
+func teacherClassKey(teacherUUID string) string {
+	return fmt.Sprintf("teachers.%s.class", teacherUUID)
+}
+
+// ScopeOutingFilter is handler narrowing grade & group in filter to scope of user, or aborting if filter is out of scope
+// it must be registered in front of redis responder, so that key of cached response is formatted with narrowed filter
+func (h *_default) ScopeOutingFilter(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.GetOutingWithFilterRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	grade, group, err := h.scopeOutingFilter(reqID, topSpan, uuidClaims.UUID, receivedReq.Grade, receivedReq.Group)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromScopeErr(err)
+		c.AbortWithStatusJSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	receivedReq.Grade, receivedReq.Group = grade, group
+	c.Next()
+}
+
+// outingScopeError is error returned if user can't get outings in filter, responded with 403 status
+type outingScopeError string
+
+func (e outingScopeError) Error() string {
+	return string(e)
+}
+
+// narrow grade & group in filter to scope of user, or return outingScopeError if filter is out of scope
+// admin & teacher with exception "all" can get every outing, and homeroom teacher can get outings in own class (or own grade)
+// it is used in every handler getting outings with filter, ex) outings with filter, excel export, statistics & stream
+func (h *_default) scopeOutingFilter(reqID string, topSpan opentracing.Span, userUUID string, grade, group int32) (scopedGrade, scopedGroup int32, err error) {
+	switch {
+	case adminUUIDRegex.MatchString(userUUID):
+		return grade, group, nil
+	case !teacherUUIDRegex.MatchString(userUUID):
+		return 0, 0, outingScopeError("only admin & teacher can get outings with filter")
+	}
+
+	scope, err := h.redisClient.HGet(ctx, outingScopeExceptionsKey, userUUID).Result()
+	if err != nil && err != redis.Nil {
+		err = errors.New(fmt.Sprintf("unable to get scope exception of teacher, err: %v", err))
+		return
+	}
+	if scope == "all" {
+		return grade, group, nil
+	}
+
+	classGrade, classGroup, err := h.getTeacherClass(reqID, topSpan, userUUID)
+	if err != nil {
+		return
+	}
+	if classGrade == 0 || (classGroup == 0 && scope != "grade") {
+		return 0, 0, outingScopeError("teacher who isn't homeroom teacher can't get outings with filter")
+	}
+
+	// narrow filter to own class (or own grade), or reject filter of another class
+	if scopedGrade, scopedGroup = grade, group; scopedGrade == 0 {
+		scopedGrade = classGrade
+	}
+	if scopedGroup == 0 && scope != "grade" {
+		scopedGroup = classGroup
+	}
+	if scopedGrade != classGrade || (scope != "grade" && scopedGroup != classGroup) {
+		return 0, 0, outingScopeError(fmt.Sprintf("you can get outings only in your class (grade: %d, group: %d)", classGrade, classGroup))
+	}
+	return
+}
+
+// get status, code & msg from error returned by scopeOutingFilter
+func (h *_default) getStatusCodeFromScopeErr(err error) (status, _code int, msg string) {
+	if scopeErr, ok := err.(outingScopeError); ok {
+		return http.StatusForbidden, 0, scopeErr.Error()
+	}
+	return h.getStatusCodeFromCallErr("GetTeacherInformWithUUID", err)
+}
+
+// get grade & group of class which teacher is in charge of, inform of teacher is cached in redis with TTL
+func (h *_default) getTeacherClass(reqID string, topSpan opentracing.Span, teacherUUID string) (grade, group int32, err error) {
+	if value, getErr := h.redisClient.Get(ctx, teacherClassKey(teacherUUID)).Result(); getErr == nil {
+		if class := strings.SplitN(value, "-", 2); len(class) == 2 {
+			gradeInt, _ := strconv.Atoi(class[0])
+			groupInt, _ := strconv.Atoi(class[1])
+			return int32(gradeInt), int32(groupInt), nil
+		}
+	}
+
+	var rpcResp *authproto.GetTeacherInformWithUUIDResponse
+	err = h.callServiceInSpan(topic.AuthServiceName, "GetTeacherInformWithUUID", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.authService.GetTeacherInformWithUUID(ctx, &authproto.GetTeacherInformWithUUIDRequest{UUID: teacherUUID, TeacherUUID: teacherUUID}, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err == nil && rpcResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetTeacherInformWithUUID responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+	}
+	if err != nil {
+		return
+	}
+
+	grade, group = int32(rpcResp.Grade), int32(rpcResp.Group)
+	h.redisClient.Set(ctx, teacherClassKey(teacherUUID), fmt.Sprintf("%d-%d", grade, group), h.TeacherClassTTL)
+	return
+}
+
+// set exception of scope of teacher getting outings with filter, only admin can set it
+func (h *_default) SetOutingScopeException(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.SetOutingScopeExceptionRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code, msg := http.StatusForbidden, 0, "only admin can set scope exception of teacher"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	teacherUUID := c.Param("teacher_uuid")
+	if !teacherUUIDRegex.MatchString(teacherUUID) {
+		status, _code, msg := http.StatusBadRequest, 0, "teacher_uuid in uri is not valid uuid of teacher"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	if err := h.redisClient.HSet(ctx, outingScopeExceptionsKey, teacherUUID, receivedReq.Scope).Err(); err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to set scope exception of teacher, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to set scope exception of teacher, teacher uuid: %s, scope: %s", teacherUUID, receivedReq.Scope)
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+}
+
+// delete exception of scope of teacher, then teacher can get outings only in own class
+func (h *_default) DeleteOutingScopeException(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code, msg := http.StatusForbidden, 0, "only admin can delete scope exception of teacher"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	deleted, err := h.redisClient.HDel(ctx, outingScopeExceptionsKey, c.Param("teacher_uuid")).Result()
+	if err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to delete scope exception of teacher, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+	if deleted == 0 {
+		status, _code, msg := http.StatusNotFound, 0, "scope exception of that teacher is not found"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	status, _code, msg := http.StatusOK, 0, "succeed to delete scope exception of teacher"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+}
@@ -0,0 +1,221 @@
+// add file in v.1.0.5
+// default_outing_stats.go is file that declare handler aggregating statistics of outings in date range
+// statistics is computed by paging through outing service & cached in redis with TTL decided by date range
+
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gateway/entity"
+	jwtutil "gateway/tool/jwt"
+	code "gateway/utils/code/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// max number of days in date range of statistics
+const maxStatisticsDays = 366
+
+// StatisticsTTLConfig is TTL policy of statistics cached in redis
+type StatisticsTTLConfig struct {
+	Current time.Duration // TTL of statistics in date range including today or future
+	Past    time.Duration // TTL of statistics in date range already passed, which rarely changes
+}
+
+// outingStatistics is counts of outings in date range, key of each map is value of dimension
+type outingStatistics struct {
+	StartDate   string         `json:"start_date"`
+	EndDate     string         `json:"end_date"`
+	Total       int            `json:"total"`
+	Late        int            `json:"late"`
+	ByStatus    map[string]int `json:"by_status"`
+	BySituation map[string]int `json:"by_situation"`
+	ByGrade     map[string]int `json:"by_grade"`
+	ByGroup     map[string]int `json:"by_group"` // key is "{grade}-{group}"
+	ByDay       map[string]int `json:"by_day"`   // key is date in school time zone
+	ByWeekday   map[string]int `json:"by_weekday"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+func newOutingStatistics(startDate, endDate string) *outingStatistics {
+	return &outingStatistics{
+		StartDate:   startDate,
+		EndDate:     endDate,
+		ByStatus:    map[string]int{},
+		BySituation: map[string]int{},
+		ByGrade:     map[string]int{},
+		ByGroup:     map[string]int{},
+		ByDay:       map[string]int{},
+		ByWeekday:   map[string]int{},
+		GeneratedAt: time.Now(),
+	}
+}
+
+// count outings scanned in statistics, outings are not counted by floor because outing & student don't have floor
+// floor is used only as filter of outings in statistics
+func (s *outingStatistics) add(outings []scannedOuting, location *time.Location) {
+	for _, outing := range outings {
+		startTime := time.Unix(outing.startTime, 0).In(location)
+		s.Total++
+		if outing.isLate {
+			s.Late++
+		}
+		s.ByStatus[fmt.Sprint(outing.status)]++
+		s.BySituation[fmt.Sprint(outing.situation)]++
+		s.ByGrade[strconv.Itoa(outing.grade)]++
+		s.ByGroup[fmt.Sprintf("%d-%d", outing.grade, outing.group)]++
+		s.ByDay[startTime.Format("2006-01-02")]++
+		s.ByWeekday[startTime.Weekday().String()]++
+	}
+}
+
+// write statistics in CSV with columns dimension, key & count
+func (s *outingStatistics) csv() []byte {
+	buf := bytes.Buffer{}
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"dimension", "key", "count"})
+	_ = w.Write([]string{"total", "", strconv.Itoa(s.Total)})
+	_ = w.Write([]string{"late", "", strconv.Itoa(s.Late)})
+	for _, dimension := range []struct {
+		name   string
+		counts map[string]int
+	}{
+		{"status", s.ByStatus}, {"situation", s.BySituation}, {"grade", s.ByGrade}, {"group", s.ByGroup},
+		{"day", s.ByDay}, {"weekday", s.ByWeekday},
+	} {
+		keys := make([]string, 0, len(dimension.counts))
+		for key := range dimension.counts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			_ = w.Write([]string{dimension.name, key, strconv.Itoa(dimension.counts[key])})
+		}
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// get statistics of outings in date range as JSON or CSV, only admin & teacher can get it
+func (h *_default) GetOutingStatistics(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.GetOutingStatisticsRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) && !teacherUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code := http.StatusForbidden, 0
+		msg := "only admin & teacher can get statistics of outings"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	// narrow filter to scope of teacher, same as GET /v1/outings/with-filter
+	var err error
+	if receivedReq.Grade, receivedReq.Group, err = h.scopeOutingFilter(reqID, topSpan, uuidClaims.UUID, receivedReq.Grade, receivedReq.Group); err != nil {
+		status, _code, msg := h.getStatusCodeFromScopeErr(err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	startDate, _ := time.ParseInLocation("2006-01-02", receivedReq.StartDate, h.location)
+	endDate, _ := time.ParseInLocation("2006-01-02", receivedReq.EndDate, h.location)
+	if endDate.Before(startDate) || endDate.Sub(startDate) >= time.Hour*24*maxStatisticsDays {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("end_date must not be before start_date, and date range must be shorter than %d days", maxStatisticsDays)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	startTime, endTime := startDate.Unix(), endDate.AddDate(0, 0, 1).Unix()
+
+	cacheKey := fmt.Sprintf("outings.statistics.start_date.%s.end_date.%s.grade.%d.group.%d.floor.%d",
+		receivedReq.StartDate, receivedReq.EndDate, receivedReq.Grade, receivedReq.Group, receivedReq.Floor)
+	stats, cached := h.getCachedOutingStatistics(cacheKey)
+	if !cached {
+		outings, truncated, rpcResp, err := h.scanOutings(reqID, topSpan, uuidClaims.UUID, receivedReq.GenerateGRPCRequest, startTime, endTime)
+		if err != nil {
+			status, _code, msg := h.getStatusCodeFromCallErr("GetOutingWithFilter", err)
+			c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+			return
+		}
+		if rpcResp.Status != http.StatusOK {
+			c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
+			entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "request": string(reqBytes)}).Info()
+			return
+		}
+		if truncated {
+			status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+			msg := fmt.Sprintf("too many outings to count (more than %d), please narrow filter such as grade, group & floor", maxScanOutings)
+			c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+			return
+		}
+
+		stats = newOutingStatistics(receivedReq.StartDate, receivedReq.EndDate)
+		stats.add(outings, h.location)
+		h.setCachedOutingStatistics(cacheKey, stats, endDate)
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to get statistics of outings, total: %d, cached: %t", stats.Total, cached)
+	switch receivedReq.Format {
+	case "csv":
+		fileName := fmt.Sprintf("outing_statistics_%s_%s.csv", receivedReq.StartDate, receivedReq.EndDate)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+		c.Data(status, "text/csv; charset=utf-8", stats.csv())
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+	default:
+		sendResp := gin.H{"status": status, "code": _code, "message": msg, "statistics": stats, "cached": cached}
+		c.JSON(status, sendResp)
+		respBytes, _ := json.Marshal(sendResp)
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+	}
+}
+
+// get statistics cached in redis, return false if not cached or unable to unmarshal
+func (h *_default) getCachedOutingStatistics(key string) (stats *outingStatistics, ok bool) {
+	value, err := h.redisClient.Get(ctx, key).Result()
+	if err != nil {
+		return
+	}
+	stats = new(outingStatistics)
+	ok = json.Unmarshal([]byte(value), stats) == nil
+	return
+}
+
+// cache statistics in redis with TTL by policy, statistics of past days is cached longer than statistics including today
+func (h *_default) setCachedOutingStatistics(key string, stats *outingStatistics, endDate time.Time) {
+	ttl := h.StatisticsTTL.Current
+	if today := time.Now().In(h.location).Format("2006-01-02"); endDate.Format("2006-01-02") < today {
+		ttl = h.StatisticsTTL.Past
+	}
+	statsBytes, _ := json.Marshal(stats)
+	h.redisClient.Set(ctx, key, string(statsBytes), ttl)
+}
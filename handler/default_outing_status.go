@@ -0,0 +1,14 @@
+// add file in v.1.0.5
+// default_outing_status.go is file that declare value of outing status in response of outing service
+
+package handler
+
+// outing status, outing moves in order of created -> parent approved -> teacher approved -> out -> ended -> certified
+const (
+	OutingStatusCreated         = "0"
+	OutingStatusParentApproved  = "1"
+	OutingStatusTeacherApproved = "2"
+	OutingStatusOut             = "3"
+	OutingStatusEnded           = "4"
+	OutingStatusCertified       = "5"
+)
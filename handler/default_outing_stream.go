@@ -0,0 +1,269 @@
+// add file in v.1.0.5
+// default_outing_stream.go is file that declare handler streaming outing event with Server-Sent Events
+// outing event is published in redis topic, so that events are fanned out to streams connected in all replica of gateway
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	authproto "gateway/proto/golang/auth"
+	outingproto "gateway/proto/golang/outing"
+	jwtutil "gateway/tool/jwt"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/micro/go-micro/v2/client"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// interval to send heartbeat comment in stream, shorter than idle timeout of AWS load balancer (60s)
+const outingStreamHeartbeat = time.Second * 25
+
+// size of event buffer per stream, event is dropped for stream if buffer is full
+const outingStreamBufferSize = 16
+
+// duration to keep student uuid of outing resolved for event, same as retention of emergency outing state
+const outingStudentRetention = emergencyRetention
+
+var (
+	teacherUUIDRegex = regexp.MustCompile("^teacher-\\d{12}$")
+	parentUUIDRegex  = regexp.MustCompile("^parent-\\d{12}$")
+	adminUUIDRegex   = regexp.MustCompile("^admin-\\d{12}$")
+
+	errForbiddenStream = errors.New("user is not allowed to stream outing events")
+)
+
+// outingEvent is event published when outing is created or action is taken in outing
+type outingEvent struct {
+	OutingUUID  string    `json:"outing_uuid"`
+	Action      string    `json:"action"` // create, start, end, teacher-approve, teacher-reject, certify, parent-approve, parent-reject, overdue
+	ActorUUID   string    `json:"actor_uuid"`
+	StudentUUID string    `json:"student_uuid"`
+	Grade       int       `json:"grade"`
+	Group       int       `json:"group"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// outingStream is stream of client connected to StreamOutingEvents, receiving events only in audience
+type outingStream struct {
+	events   chan outingEvent
+	all      bool            // receive all events (admin, or teacher with scope exception "all")
+	grade    int             // receive events of students in grade if not zero
+	group    int             // receive events of students in group of grade if not zero (add in v.1.0.5)
+	students map[string]bool // receive events of students in set
+}
+
+// check if event is in audience of stream
+func (s *outingStream) accept(event outingEvent) bool {
+	switch {
+	case s.all:
+		return true
+	case s.grade != 0:
+		return event.Grade == s.grade && (s.group == 0 || event.Group == s.group)
+	default:
+		return s.students[event.StudentUUID]
+	}
+}
+
+// stream outing events in audience of user with Server-Sent Events
+// teacher receives events in scope of outing filter, parent receives events of children, student receives events of own outings
+func (h *_default) StreamOutingEvents(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	stream, err := h.newOutingStream(uuidClaims.UUID)
+	switch err {
+	case nil:
+		break
+	case errForbiddenStream:
+		status, _code := http.StatusForbidden, 0
+		msg := "you are not allowed to stream outing events"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	default:
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to resolve audience of outing stream, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+
+	h.streamMutex.Lock()
+	h.streams[stream] = struct{}{}
+	h.streamMutex.Unlock()
+	defer func() {
+		h.streamMutex.Lock()
+		delete(h.streams, stream)
+		h.streamMutex.Unlock()
+	}()
+
+	entry.WithFields(logrus.Fields{"status": http.StatusOK, "code": 0, "message": "start to stream outing events"}).Info()
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	heartbeat := time.NewTicker(outingStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-stream.events:
+			c.SSEvent("outing", event)
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": heartbeat\n\n")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// create outing stream with audience resolved by type of user in uuid
+func (h *_default) newOutingStream(uuid string) (stream *outingStream, err error) {
+	stream = &outingStream{events: make(chan outingEvent, outingStreamBufferSize), students: map[string]bool{}}
+
+	switch {
+	case adminUUIDRegex.MatchString(uuid):
+		stream.all = true
+	case studentUUIDRegex.MatchString(uuid):
+		stream.students[uuid] = true
+	case teacherUUIDRegex.MatchString(uuid):
+		// teacher receives events in scope same as outings with filter, ex) own class of homeroom teacher
+		grade, group, scopeErr := h.scopeOutingFilter("", nil, uuid, 0, 0)
+		if _, ok := scopeErr.(outingScopeError); ok {
+			err = errForbiddenStream
+			return
+		} else if scopeErr != nil {
+			err = scopeErr
+			return
+		}
+		stream.grade, stream.group = int(grade), int(group)
+		stream.all = stream.grade == 0
+	case parentUUIDRegex.MatchString(uuid):
+		var rpcResp *authproto.GetChildrenInformsWithUUIDResponse
+		err = h.callService(topic.AuthServiceName, "GetChildrenInformsWithUUID", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcResp, rpcErr = h.authService.GetChildrenInformsWithUUID(ctx, &authproto.GetChildrenInformsWithUUIDRequest{UUID: uuid, ParentUUID: uuid}, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		if err == nil && rpcResp.Status != http.StatusOK {
+			err = errors.New(fmt.Sprintf("GetChildrenInformsWithUUID responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+		}
+		if err != nil {
+			return
+		}
+		for _, child := range rpcResp.ChildrenInform {
+			stream.students[child.StudentUUID] = true
+		}
+	default:
+		err = errForbiddenStream
+	}
+	return
+}
+
+// publish outing event in redis topic after resolving student of outing, run in another goroutine not to delay response
+func (h *_default) publishOutingEvent(event outingEvent) {
+	// record action in timeline of emergency outing, and cancel escalation if resolved
+	go h.trackEmergencyOutingAction(event.OutingUUID, event.Action, event.ActorUUID)
+
+	if h.outingEventTopic == "" {
+		return
+	}
+	event.OccurredAt = time.Now()
+
+	go func() {
+		if err := h.resolveOutingEvent(&event); err != nil {
+			log.Errorf("unable to resolve student of outing event, outing uuid: %s, err: %v", event.OutingUUID, err)
+		}
+
+		eventBytes, _ := json.Marshal(event)
+		if err := h.redisClient.Publish(ctx, h.outingEventTopic, string(eventBytes)).Err(); err != nil {
+			log.Errorf("unable to publish outing event in redis, topic: %s, err: %v", h.outingEventTopic, err)
+		}
+	}()
+}
+
+// fill student uuid, grade & group of student in outing event
+// student uuid is found in redis key set when outing was created, or is got from outing service with uuid of actor
+func (h *_default) resolveOutingEvent(event *outingEvent) (err error) {
+	studentKey := fmt.Sprintf("outings.%s.student_uuid", event.OutingUUID)
+	switch {
+	case event.StudentUUID != "":
+		h.redisClient.Set(ctx, studentKey, event.StudentUUID, outingStudentRetention)
+	case studentUUIDRegex.MatchString(event.ActorUUID):
+		event.StudentUUID = event.ActorUUID
+	default:
+		if event.StudentUUID, err = h.redisClient.Get(ctx, studentKey).Result(); err == nil {
+			break
+		}
+		if err != redis.Nil || event.ActorUUID == "" {
+			return
+		}
+
+		var rpcResp *outingproto.GetOutingInformResponse
+		err = h.callService(topic.OutingServiceName, "GetOutingInform", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcResp, rpcErr = h.outingService.GetOutingInform(ctx, &outingproto.GetOutingInformRequest{Uuid: event.ActorUUID, OutingId: event.OutingUUID}, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		if err == nil && rpcResp.Status != http.StatusOK {
+			err = errors.New(fmt.Sprintf("GetOutingInform responses with %d status, msg: %s", rpcResp.Status, rpcResp.Msg))
+		}
+		if err != nil {
+			return
+		}
+		event.StudentUUID = rpcResp.StudentUuid
+		h.redisClient.Set(ctx, studentKey, event.StudentUUID, outingStudentRetention)
+	}
+
+	var rpcResp *authproto.GetStudentInformWithUUIDResponse
+	err = h.callService(topic.AuthServiceName, "GetStudentInformWithUUID", func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.authService.GetStudentInformWithUUID(ctx, &authproto.GetStudentInformWithUUIDRequest{UUID: event.StudentUUID, StudentUUID: event.StudentUUID}, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err == nil && rpcResp.Status != http.StatusOK {
+		err = errors.New(fmt.Sprintf("GetStudentInformWithUUID responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+	}
+	if err != nil {
+		return
+	}
+	event.Grade, event.Group = int(rpcResp.Grade), int(rpcResp.Group)
+	return
+}
+
+// send outing event in redis message to streams connected in this gateway, if event is in audience of stream
+func (h *_default) BroadcastOutingEvent(msg *redis.Message) (err error) {
+	var event outingEvent
+	if err = json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+		err = errors.New(fmt.Sprintf("unable to unmarshal outing event msg to golang struct, err: %v", err))
+		return
+	}
+
+	h.streamMutex.RLock()
+	defer h.streamMutex.RUnlock()
+	for stream := range h.streams {
+		if !stream.accept(event) {
+			continue
+		}
+		select {
+		case stream.events <- event:
+		default:
+			log.Errorf("event buffer of outing stream is full, drop event, outing uuid: %s", event.OutingUUID)
+		}
+	}
+	return
+}
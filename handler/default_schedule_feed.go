@@ -0,0 +1,289 @@
+// add file in v.1.0.5
+// default_schedule_feed.go is file that declare subscribable iCalendar(RFC 5545) feed of school schedules
+// calendar app can't send Bearer JWT, so feed is accessed with per-user feed token which can be revoked by user
+
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	scheduleproto "gateway/proto/golang/schedule"
+	jwtutil "gateway/tool/jwt"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ScheduleFeedConfig is config of iCalendar feed, feed aggregates months from PastMonths ago to FutureMonths later
+type ScheduleFeedConfig struct {
+	PastMonths   int
+	FutureMonths int
+	CacheTTL     time.Duration
+}
+
+// feed token is mapped to user uuid & user uuid is mapped to feed token, so that token can be revoked by user
+// they must not start with "schedules", or they are deleted in invalidation of schedules
+func scheduleFeedTokenKey(token string) string {
+	return fmt.Sprintf("calendar-feeds.tokens.%s", token)
+}
+
+func scheduleFeedUserKey(userUUID string) string {
+	return fmt.Sprintf("calendar-feeds.users.%s", userUUID)
+}
+
+// feed is same for every user, so it is cached per month range and deleted in invalidation of schedules
+func scheduleFeedCacheKey(from time.Time, months int) string {
+	return fmt.Sprintf("schedules.feeds.from.%s.months.%d", from.Format("2006-01"), months)
+}
+
+// issue new feed token of user, previous feed token of user is revoked
+func (h *_default) IssueScheduleFeedToken(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	tokenBytes := make([]byte, 24)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to generate feed token, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	if err := h.revokeScheduleFeedToken(uuidClaims.UUID); err != nil && err != redis.Nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to revoke previous feed token, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+
+	pipe := h.redisClient.TxPipeline()
+	pipe.Set(ctx, scheduleFeedTokenKey(token), uuidClaims.UUID, 0)
+	pipe.Set(ctx, scheduleFeedUserKey(uuidClaims.UUID), token, 0)
+	if _, err := pipe.Exec(ctx); err != nil {
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to save feed token, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+
+	status, _code, msg := http.StatusCreated, 0, "succeed to issue feed token of schedules"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg,
+		"feed_token": token, "feed_path": fmt.Sprintf("/v1/schedules/feeds/%s/calendar.ics", token)})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+}
+
+// revoke feed token of user, calendar app subscribing feed with that token can't get feed anymore
+func (h *_default) RevokeScheduleFeedToken(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	switch err := h.revokeScheduleFeedToken(uuidClaims.UUID); err {
+	case nil:
+	case redis.Nil:
+		status, _code, msg := http.StatusNotFound, 0, "feed token of schedules is not issued yet"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	default:
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to revoke feed token, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+
+	status, _code, msg := http.StatusOK, 0, "succeed to revoke feed token of schedules"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+}
+
+// delete feed token of user, return redis.Nil if user doesn't have feed token
+func (h *_default) revokeScheduleFeedToken(userUUID string) (err error) {
+	token, err := h.redisClient.Get(ctx, scheduleFeedUserKey(userUUID)).Result()
+	if err != nil {
+		return
+	}
+	_, err = h.redisClient.Del(ctx, scheduleFeedTokenKey(token), scheduleFeedUserKey(userUUID)).Result()
+	return
+}
+
+// get iCalendar feed of schedules with feed token in uri, this handler must be routed without authenticator
+func (h *_default) GetScheduleFeed(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	userUUID, err := h.redisClient.Get(ctx, scheduleFeedTokenKey(c.Param("token"))).Result()
+	switch err {
+	case nil:
+		entry = entry.WithField("user_uuid", userUUID)
+	case redis.Nil:
+		status, _code, msg := http.StatusUnauthorized, 0, "feed token is invalid or revoked"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	default:
+		status, _code, msg := http.StatusInternalServerError, 0, fmt.Sprintf("unable to get feed token, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+
+	now := time.Now().In(h.location)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, h.location).AddDate(0, -h.FeedCfg.PastMonths, 0)
+	months := h.FeedCfg.PastMonths + h.FeedCfg.FutureMonths + 1
+	cacheKey := scheduleFeedCacheKey(from, months)
+
+	if feed, err := h.redisClient.Get(ctx, cacheKey).Result(); err == nil {
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed))
+		entry.WithFields(logrus.Fields{"status": http.StatusOK, "code": 0, "message": "succeed to get schedule feed in redis"}).Info()
+		return
+	}
+
+	schedules, err := h.getSchedulesInMonths(reqID, topSpan, userUUID, from, months)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetSchedule", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+
+	feed := newICalendar(schedules, h.location, time.Now())
+	h.redisClient.Set(ctx, cacheKey, feed, h.FeedCfg.CacheTTL)
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed))
+	msg := fmt.Sprintf("succeed to get schedule feed, from: %s, months: %d", from.Format("2006-01"), months)
+	entry.WithFields(logrus.Fields{"status": http.StatusOK, "code": 0, "message": msg}).Info()
+}
+
+// feedSchedule is schedule emitted as VEVENT in feed, start & end date are unix time in seconds
+type feedSchedule struct {
+	UUID      string
+	StartDate int64
+	EndDate   int64
+	Detail    string
+}
+
+// get schedules in months from schedule service with uuid of user, schedule lasting across months is included only once
+func (h *_default) getSchedulesInMonths(reqID string, topSpan opentracing.Span, userUUID string, from time.Time, months int) (schedules []feedSchedule, err error) {
+	included := map[string]bool{}
+	for i := 0; i < months; i++ {
+		month := from.AddDate(0, i, 0)
+		rpcReq := &scheduleproto.GetScheduleRequest{Uuid: userUUID, Year: int32(month.Year()), Month: int32(month.Month())}
+
+		var rpcResp *scheduleproto.GetScheduleResponse
+		err = h.callServiceInSpan(topic.ScheduleServiceName, "GetSchedule", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcResp, rpcErr = h.scheduleService.GetSchedule(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		if err == nil && rpcResp.Status != http.StatusOK {
+			err = errors.New(fmt.Sprintf("GetSchedule responses with %d status, msg: %s", rpcResp.Status, rpcResp.Msg))
+		}
+		if err != nil {
+			return
+		}
+
+		for _, schedule := range rpcResp.Schedule {
+			if included[schedule.ScheduleUUID] {
+				continue
+			}
+			included[schedule.ScheduleUUID] = true
+			schedules = append(schedules, feedSchedule{
+				UUID:      schedule.ScheduleUUID,
+				StartDate: int64(schedule.StartDate),
+				EndDate:   int64(schedule.EndDate),
+				Detail:    schedule.Detail,
+			})
+		}
+	}
+	return
+}
+
+// make iCalendar object with schedules, schedule is all-day event from start date to end date in school time zone
+func newICalendar(schedules []feedSchedule, location *time.Location, stampedAt time.Time) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//DMS//School Schedule Feed//KO",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeICalText("학사일정"),
+		"X-WR-TIMEZONE:" + location.String(),
+	}
+
+	dtStamp := stampedAt.UTC().Format("20060102T150405Z")
+	for _, schedule := range schedules {
+		start := time.Unix(schedule.StartDate, 0).In(location)
+		end := time.Unix(schedule.EndDate, 0).In(location)
+		if end.Before(start) {
+			end = start
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%s@dms", schedule.UUID),
+			"DTSTAMP:"+dtStamp,
+			"DTSTART;VALUE=DATE:"+start.Format("20060102"),
+			"DTEND;VALUE=DATE:"+end.AddDate(0, 0, 1).Format("20060102"), // DTEND of all-day event is exclusive
+			"SUMMARY:"+escapeICalText(schedule.Detail),
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(foldICalLine(line))
+	}
+	return builder.String()
+}
+
+// escape backslash, semicolon, comma & newline in TEXT value (RFC 5545 3.3.11)
+func escapeICalText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// fold line longer than 75 octets without splitting UTF-8 character, and end it with CRLF (RFC 5545 3.1)
+func foldICalLine(line string) string {
+	var builder strings.Builder
+	octets := 0
+	for _, r := range line {
+		size := len(string(r))
+		if octets+size > 75 {
+			builder.WriteString("\r\n ")
+			octets = 1
+		}
+		builder.WriteRune(r)
+		octets += size
+	}
+	builder.WriteString("\r\n")
+	return builder.String()
+}
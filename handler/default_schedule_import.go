@@ -0,0 +1,467 @@
+// add file in v.1.0.5
+// default_schedule_import.go is file that declare handler importing schedules in bulk from iCalendar(.ics) or Excel(.xlsx) file
+// every row is parsed into CreateScheduleRequest & validated first, and then created concurrently in schedule service
+
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/entity"
+	scheduleproto "gateway/proto/golang/schedule"
+	jwtutil "gateway/tool/jwt"
+	code "gateway/utils/code/golang"
+	topic "gateway/utils/topic/golang"
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// max number of schedules in one imported file & max number of schedules created at the same time
+const (
+	maxImportedSchedules          = 500
+	maxConcurrentScheduleCreation = 8
+)
+
+// action of each row in import report
+const (
+	importActionCreate  = "create"
+	importActionSkip    = "skip"    // same schedule already exists or is in previous row
+	importActionInvalid = "invalid" // row can't be parsed or is not valid for CreateScheduleRequest
+)
+
+// layouts of date in cell of Excel file, date without time is regarded as midnight in school time zone
+var importDateLayouts = []string{"2006-01-02", "2006.01.02", "2006/01/02", "2006-1-2", "2006.1.2", "2006/1/2", "01-02-06", "1/2/06"}
+
+// importedSchedule is row parsed from imported file, with result of previewing or creating it
+type importedSchedule struct {
+	Row          int    `json:"row"`
+	StartDate    int64  `json:"start_date"`
+	EndDate      int64  `json:"end_date"`
+	Detail       string `json:"detail"`
+	Action       string `json:"action"`
+	Status       int    `json:"status,omitempty"`
+	Code         int    `json:"code,omitempty"`
+	Message      string `json:"message,omitempty"`
+	ScheduleUUID string `json:"schedule_uuid,omitempty"`
+
+	request *entity.CreateScheduleRequest
+}
+
+// import schedules from .ics or .xlsx file, only preview of import is responded if dry_run is true
+// status is 201 only if at least one schedule is created, so that schedules in redis are deleted once after import
+func (h *_default) ImportSchedules(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.ImportSchedulesRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	f, err := receivedReq.File.Open()
+	if err != nil {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("unable to open imported file, file name: %s, err: %v", receivedReq.File.Filename, err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var schedules []*importedSchedule
+	switch name := strings.ToLower(receivedReq.File.Filename); {
+	case strings.HasSuffix(name, ".ics"):
+		schedules, err = h.parseSchedulesFromICalendar(f)
+	case strings.HasSuffix(name, ".xlsx"):
+		schedules, err = h.parseSchedulesFromExcel(f)
+	default:
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("imported file must be .ics or .xlsx, file name: %s", receivedReq.File.Filename)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	if err == nil && len(schedules) == 0 {
+		err = errors.New("there is no schedule in imported file")
+	}
+	if err == nil && len(schedules) > maxImportedSchedules {
+		err = errors.New(fmt.Sprintf("number of schedules in imported file must be less than or equal to %d", maxImportedSchedules))
+	}
+	if err != nil {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("unable to parse imported file, file name: %s, err: %v", receivedReq.File.Filename, err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	// validate every row with same validator as POST /v1/schedules
+	invalidNum := 0
+	for _, schedule := range schedules {
+		if schedule.Action == importActionInvalid {
+			invalidNum++
+			continue
+		}
+		if err := h.validate.Struct(schedule.request); err != nil {
+			schedule.Action, schedule.Status, schedule.Message = importActionInvalid, http.StatusBadRequest, err.Error()
+			invalidNum++
+		}
+	}
+
+	// compare with schedules which already exist in months of imported schedules
+	if err := h.previewImportedSchedules(reqID, topSpan, uuidClaims.UUID, schedules); err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetSchedule", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	createNum := 0
+	for _, schedule := range schedules {
+		if schedule.Action == importActionCreate {
+			createNum++
+		}
+	}
+
+	if receivedReq.DryRun {
+		status, _code := http.StatusOK, 0
+		msg := fmt.Sprintf("succeed to preview import of schedules, create: %d, skip: %d, invalid: %d", createNum, len(schedules)-createNum-invalidNum, invalidNum)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "schedules": schedules})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	if invalidNum != 0 {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("imported file has %d invalid schedules, no schedule is created", invalidNum)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "schedules": schedules})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	var toCreate []*importedSchedule
+	for _, schedule := range schedules {
+		if schedule.Action == importActionCreate {
+			toCreate = append(toCreate, schedule)
+		}
+	}
+	forEachConcurrently(len(toCreate), maxConcurrentScheduleCreation, func(i int) {
+		schedule := toCreate[i]
+		rpcReq := schedule.request.GenerateGRPCRequest()
+		rpcReq.Uuid = uuidClaims.UUID
+		var rpcResp *scheduleproto.DefaultScheduleResponse
+		err := h.callServiceInSpan(topic.ScheduleServiceName, "CreateSchedule", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcResp, rpcErr = h.scheduleService.CreateSchedule(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		if err != nil {
+			schedule.Status, schedule.Code, schedule.Message = h.getStatusCodeFromCallErr("CreateSchedule", err)
+			return
+		}
+		schedule.Status, schedule.Code, schedule.Message = int(rpcResp.Status), int(rpcResp.Code), rpcResp.Msg
+		if rpcResp.Status == http.StatusCreated {
+			schedule.Message, schedule.ScheduleUUID = "succeed to create new schedule", rpcResp.ScheduleUUID
+		}
+	})
+
+	createdNum := 0
+	for _, schedule := range schedules {
+		if schedule.Action == importActionCreate && schedule.Status == http.StatusCreated {
+			createdNum++
+		}
+	}
+
+	status, _code := http.StatusOK, 0
+	if createdNum != 0 {
+		status = http.StatusCreated
+	}
+	msg := fmt.Sprintf("succeed to import schedules, created num: %d, failed num: %d, skipped num: %d", createdNum, createNum-createdNum, len(schedules)-createNum)
+	sendResp := gin.H{"status": status, "code": _code, "message": msg, "schedules": schedules}
+	c.JSON(status, sendResp)
+	respBytes, _ := json.Marshal(sendResp)
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+}
+
+// set action of valid schedules to skip if same schedule exists in schedule service or in previous row, or to create
+func (h *_default) previewImportedSchedules(reqID string, topSpan opentracing.Span, userUUID string, schedules []*importedSchedule) (err error) {
+	var months []time.Time
+	included := map[string]bool{}
+	for _, schedule := range schedules {
+		if schedule.Action == importActionInvalid {
+			continue
+		}
+		start := time.Unix(schedule.StartDate, 0).In(h.location)
+		month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, h.location)
+		if !included[month.Format("2006-01")] {
+			included[month.Format("2006-01")] = true
+			months = append(months, month)
+		}
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+
+	existing := map[string]bool{}
+	for _, month := range months {
+		inMonth, err := h.getSchedulesInMonths(reqID, topSpan, userUUID, month, 1)
+		if err != nil {
+			return err
+		}
+		for _, schedule := range inMonth {
+			existing[h.scheduleIdentity(schedule.StartDate, schedule.EndDate, schedule.Detail)] = true
+		}
+	}
+
+	for _, schedule := range schedules {
+		if schedule.Action == importActionInvalid {
+			continue
+		}
+		identity := h.scheduleIdentity(schedule.StartDate, schedule.EndDate, schedule.Detail)
+		if existing[identity] {
+			schedule.Action, schedule.Message = importActionSkip, "same schedule already exists"
+			continue
+		}
+		existing[identity] = true
+		schedule.Action = importActionCreate
+	}
+	return
+}
+
+// schedules are regarded as same if start date, end date (in school time zone) & detail are same
+func (h *_default) scheduleIdentity(startDate, endDate int64, detail string) string {
+	start := time.Unix(startDate, 0).In(h.location).Format("2006-01-02")
+	end := time.Unix(endDate, 0).In(h.location).Format("2006-01-02")
+	return fmt.Sprintf("%s/%s/%s", start, end, strings.TrimSpace(detail))
+}
+
+// make imported schedule of row, end date must be last day of schedule (inclusive)
+func newImportedSchedule(row int, start, end time.Time, detail string) *importedSchedule {
+	return &importedSchedule{
+		Row:       row,
+		StartDate: start.Unix(),
+		EndDate:   end.Unix(),
+		Detail:    detail,
+		request: &entity.CreateScheduleRequest{
+			StartDate: entity.Timestamp(start.Unix()),
+			EndDate:   entity.Timestamp(end.Unix()),
+			Detail:    detail,
+		},
+	}
+}
+
+func invalidImportedSchedule(row int, detail, msg string) *importedSchedule {
+	return &importedSchedule{Row: row, Detail: detail, Action: importActionInvalid, Status: http.StatusBadRequest, Message: msg}
+}
+
+// parse VEVENTs in iCalendar file into schedules, row of schedule is order of VEVENT starting from 1
+// recurrence rule (RRULE) isn't expanded, so only first occurrence of recurring event is imported
+func (h *_default) parseSchedulesFromICalendar(r io.Reader) (schedules []*importedSchedule, err error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// unfold line starting with white space into previous line (RFC 5545 3.1)
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) != 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	var event map[string]string
+	row := 0
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			event = map[string]string{}
+			row++
+			continue
+		case line == "END:VEVENT" && event != nil:
+			schedules = append(schedules, h.scheduleFromICalEvent(row, event))
+			event = nil
+			continue
+		case event == nil:
+			continue
+		}
+
+		// ex) DTSTART;VALUE=DATE:20201018 -> name: DTSTART, params: VALUE=DATE, value: 20201018
+		sep := strings.Index(line, ":")
+		if sep < 0 {
+			continue
+		}
+		nameWithParams, value := line[:sep], line[sep+1:]
+		name := strings.ToUpper(strings.SplitN(nameWithParams, ";", 2)[0])
+		if _, exist := event[name]; !exist {
+			event[name] = value
+			event[name+";"] = nameWithParams
+		}
+	}
+	return
+}
+
+// make schedule with DTSTART, DTEND & SUMMARY of VEVENT, DTEND of all-day event is exclusive
+func (h *_default) scheduleFromICalEvent(row int, event map[string]string) *importedSchedule {
+	detail := unescapeICalText(event["SUMMARY"])
+	start, startIsDate, err := h.parseICalTime(event["DTSTART;"], event["DTSTART"])
+	if err != nil {
+		return invalidImportedSchedule(row, detail, fmt.Sprintf("unable to parse DTSTART, err: %v", err))
+	}
+
+	end := start
+	if value, exist := event["DTEND"]; exist {
+		var endIsDate bool
+		if end, endIsDate, err = h.parseICalTime(event["DTEND;"], value); err != nil {
+			return invalidImportedSchedule(row, detail, fmt.Sprintf("unable to parse DTEND, err: %v", err))
+		}
+		if endIsDate && startIsDate && end.After(start) {
+			end = end.AddDate(0, 0, -1)
+		}
+	}
+
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, h.location)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, h.location)
+	return newImportedSchedule(row, start, end, detail)
+}
+
+// parse DATE or DATE-TIME value of property in school time zone, time in UTC or with TZID parameter is converted to it
+func (h *_default) parseICalTime(nameWithParams, value string) (t time.Time, isDate bool, err error) {
+	location := h.location
+	for _, param := range strings.Split(nameWithParams, ";")[1:] {
+		if kv := strings.SplitN(param, "=", 2); len(kv) == 2 && strings.ToUpper(kv[0]) == "TZID" {
+			if tzLocation, loadErr := time.LoadLocation(strings.Trim(kv[1], "\"")); loadErr == nil {
+				location = tzLocation
+			}
+		}
+	}
+
+	switch {
+	case len(value) == len("20060102"):
+		t, err = time.ParseInLocation("20060102", value, h.location)
+		isDate = true
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, location)
+	}
+	t = t.In(h.location)
+	return
+}
+
+// unescape TEXT value (RFC 5545 3.3.11), reverse of escapeICalText
+func unescapeICalText(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+// parse rows in first sheet of Excel file into schedules, first row must be header having start date, end date & detail
+// row of schedule is row number in sheet, and end date is same as start date if it is blank
+func (h *_default) parseSchedulesFromExcel(r io.Reader) (schedules []*importedSchedule, err error) {
+	excel, err := excelize.OpenReader(r)
+	if err != nil {
+		return
+	}
+	rows, err := excel.GetRows(excel.GetSheetName(excel.GetActiveSheetIndex()))
+	if err != nil {
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	startIndex, endIndex, detailIndex := -1, -1, -1
+	for i, attr := range rows[0] {
+		switch strings.TrimSpace(attr) {
+		case "시작일", "시작 날짜", "start_date":
+			startIndex = i
+		case "종료일", "종료 날짜", "end_date":
+			endIndex = i
+		case "일정", "내용", "detail":
+			detailIndex = i
+		}
+	}
+	if startIndex < 0 || detailIndex < 0 {
+		err = errors.New("first row must have header of start date (시작일, start_date) & detail (일정, detail)")
+		return
+	}
+
+	cell := func(row []string, index int) string {
+		if index < 0 || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		startValue, endValue, detail := cell(row, startIndex), cell(row, endIndex), cell(row, detailIndex)
+		if startValue == "" && endValue == "" && detail == "" {
+			continue
+		}
+
+		start, err := h.parseExcelDate(startValue)
+		if err != nil {
+			schedules = append(schedules, invalidImportedSchedule(rowNum, detail, fmt.Sprintf("unable to parse start date, err: %v", err)))
+			continue
+		}
+		end := start
+		if endValue != "" {
+			if end, err = h.parseExcelDate(endValue); err != nil {
+				schedules = append(schedules, invalidImportedSchedule(rowNum, detail, fmt.Sprintf("unable to parse end date, err: %v", err)))
+				continue
+			}
+		}
+		schedules = append(schedules, newImportedSchedule(rowNum, start, end, detail))
+	}
+	return schedules, nil
+}
+
+// parse date in cell, which can be date string, ISO-8601 string, epoch seconds or serial number of Excel date
+func (h *_default) parseExcelDate(value string) (t time.Time, err error) {
+	if parsed, parseErr := time.Parse(time.RFC3339, value); parseErr == nil {
+		t = parsed.In(h.location)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, h.location), nil
+	}
+	for _, layout := range importDateLayouts {
+		if t, err = time.ParseInLocation(layout, value, h.location); err == nil {
+			return
+		}
+	}
+	if number, parseErr := strconv.ParseFloat(value, 64); parseErr == nil {
+		if number >= 1e9 && number < 1e10 {
+			t = time.Unix(int64(number), 0).In(h.location)
+		} else if t, err = excelize.ExcelDateToTime(number, false); err != nil {
+			return
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, h.location), nil
+	}
+	err = errors.New(fmt.Sprintf("unsupported format of date, value: %s", value))
+	return
+}
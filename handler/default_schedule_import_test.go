@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseICalTime(t *testing.T) {
+	h := &_default{location: testLocation}
+
+	for _, c := range []struct {
+		name           string
+		nameWithParams string
+		value          string
+		expected       time.Time
+		isDate         bool
+		isErr          bool
+	}{
+		{"date", "DTSTART;VALUE=DATE", "20200907", time.Date(2020, 9, 7, 0, 0, 0, 0, testLocation), true, false},
+		{"local date time", "DTSTART", "20200907T093000", time.Date(2020, 9, 7, 9, 30, 0, 0, testLocation), false, false},
+		{"date time in UTC", "DTSTART", "20200907T003000Z", time.Date(2020, 9, 7, 9, 30, 0, 0, testLocation), false, false},
+		{"date time with TZID", "DTSTART;TZID=America/New_York", "20200906T203000", time.Date(2020, 9, 7, 9, 30, 0, 0, testLocation), false, false},
+		{"date time with quoted TZID", `DTSTART;TZID="America/New_York"`, "20200906T203000", time.Date(2020, 9, 7, 9, 30, 0, 0, testLocation), false, false},
+		{"date time with unknown TZID", "DTSTART;TZID=Unknown/Zone", "20200907T093000", time.Date(2020, 9, 7, 9, 30, 0, 0, testLocation), false, false},
+		{"date ignores TZID", "DTSTART;TZID=America/New_York;VALUE=DATE", "20200907", time.Date(2020, 9, 7, 0, 0, 0, 0, testLocation), true, false},
+		{"invalid value", "DTSTART", "2020-09-07", time.Time{}, false, true},
+	} {
+		parsed, isDate, err := h.parseICalTime(c.nameWithParams, c.value)
+		if c.isErr {
+			assert.Error(t, err, c.name)
+			continue
+		}
+		assert.NoError(t, err, c.name)
+		assert.True(t, c.expected.Equal(parsed), "%s, expected: %s, actual: %s", c.name, c.expected, parsed)
+		assert.Equal(t, testLocation, parsed.Location(), c.name)
+		assert.Equal(t, c.isDate, isDate, c.name)
+	}
+}
@@ -0,0 +1,643 @@
+// add file in v.1.0.5
+// default_schedule_series.go is file that declare handler about recurring schedule series, expanded into schedules at gateway
+// schedule service doesn't know series, so series & schedule uuid of each occurrence are saved in redis by gateway
+// occurrence is identified with its original start date in school time zone, like RECURRENCE-ID of iCalendar
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/entity"
+	scheduleproto "gateway/proto/golang/schedule"
+	jwtutil "gateway/tool/jwt"
+	code "gateway/utils/code/golang"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/micro/go-micro/v2/client"
+	log "github.com/micro/go-micro/v2/logger"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// max number of occurrences expanded from one series, and TTL of lock held while series is being changed
+// lock is renewed in every third of TTL until it is released, because calls to schedule service may take longer than TTL
+const (
+	maxScheduleOccurrences = 100
+	scheduleSeriesLockTTL  = time.Second * 30
+)
+
+var (
+	// renew TTL of series lock only if lock is held by this request
+	renewScheduleSeriesLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+	// release series lock only if lock is held by this request, so that lock taken by another request after expiry is kept
+	unlockScheduleSeriesScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+)
+
+// scope of occurrences changed in series operation
+const (
+	seriesScopeThis      = "this"
+	seriesScopeFollowing = "following"
+	seriesScopeAll       = "all"
+)
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// key of series must not start with "schedules", or it is deleted in invalidation of schedules
+func scheduleSeriesKey(seriesUUID string) string {
+	return fmt.Sprintf("schedule-series.%s", seriesUUID)
+}
+
+func scheduleSeriesLockKey(seriesUUID string) string {
+	return fmt.Sprintf("schedule-series.%s.lock", seriesUUID)
+}
+
+// scheduleSeries is recurring schedule saved in redis, occurrences are sorted by date
+type scheduleSeries struct {
+	SeriesUUID  string                `json:"series_uuid"`
+	WriterUUID  string                `json:"writer_uuid"`
+	Detail      string                `json:"detail"`
+	Recurrence  entity.RecurrenceRule `json:"recurrence"`
+	Occurrences []scheduleOccurrence  `json:"occurrences"`
+}
+
+// scheduleOccurrence is schedule created from series, Date is original start date of occurrence (2006-01-02)
+type scheduleOccurrence struct {
+	Date         string `json:"date"`
+	ScheduleUUID string `json:"schedule_uuid"`
+	StartDate    int64  `json:"start_date"`
+	EndDate      int64  `json:"end_date"`
+	Detail       string `json:"detail"`
+}
+
+// result of creating, updating or deleting each occurrence in series operation
+type occurrenceResult struct {
+	Date         string `json:"date"`
+	ScheduleUUID string `json:"schedule_uuid,omitempty"`
+	Status       int    `json:"status"`
+	Code         int    `json:"code"`
+	Message      string `json:"message"`
+}
+
+// create recurring schedule series, every occurrence expanded from recurrence rule is created in schedule service
+func (h *_default) CreateScheduleSeries(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.CreateScheduleSeriesRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	start := time.Unix(receivedReq.StartDate.Unix(), 0).In(h.location)
+	duration := receivedReq.EndDate.Unix() - receivedReq.StartDate.Unix()
+	starts, err := expandRecurrence(start, receivedReq.Recurrence, h.location)
+	if err == nil && duration < 0 {
+		err = errors.New("end_date must not be before start_date")
+	}
+	if err != nil {
+		status, _code, msg := http.StatusBadRequest, code.IntegrityInvalidRequest, err.Error()
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	occurrences := make([]scheduleOccurrence, len(starts))
+	results := make([]occurrenceResult, len(starts))
+	forEachConcurrently(len(starts), maxConcurrentScheduleCreation, func(i int) {
+		occurrences[i] = scheduleOccurrence{
+			Date:      starts[i].Format("2006-01-02"),
+			StartDate: starts[i].Unix(),
+			EndDate:   starts[i].Unix() + duration,
+			Detail:    receivedReq.Detail,
+		}
+		rpcReq := entity.CreateScheduleRequest{
+			StartDate: entity.Timestamp(occurrences[i].StartDate),
+			EndDate:   entity.Timestamp(occurrences[i].EndDate),
+			Detail:    occurrences[i].Detail,
+		}.GenerateGRPCRequest()
+		rpcReq.Uuid = uuidClaims.UUID
+
+		var rpcResp *scheduleproto.DefaultScheduleResponse
+		err := h.callServiceInSpan(topic.ScheduleServiceName, "CreateSchedule", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcResp, rpcErr = h.scheduleService.CreateSchedule(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		results[i] = h.occurrenceResultOf(occurrences[i].Date, "CreateSchedule", rpcResp, err, http.StatusCreated)
+		occurrences[i].ScheduleUUID = rpcResp.GetScheduleUUID()
+	})
+
+	series := scheduleSeries{
+		SeriesUUID: uuid.New().String(),
+		WriterUUID: uuidClaims.UUID,
+		Detail:     receivedReq.Detail,
+		Recurrence: receivedReq.Recurrence,
+	}
+	for i, result := range results {
+		if result.Status == http.StatusCreated {
+			series.Occurrences = append(series.Occurrences, occurrences[i])
+		}
+	}
+	if len(series.Occurrences) == 0 {
+		status, _code := results[0].Status, results[0].Code
+		msg := fmt.Sprintf("unable to create any occurrence of schedule series, msg: %s", results[0].Message)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "occurrences": results})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	if err := h.saveScheduleSeries(series); err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("schedules are created, but unable to save schedule series, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "occurrences": results})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	status, _code := http.StatusCreated, 0
+	msg := fmt.Sprintf("succeed to create schedule series, created num: %d, failed num: %d", len(series.Occurrences), len(results)-len(series.Occurrences))
+	sendResp := gin.H{"status": status, "code": _code, "message": msg, "series_uuid": series.SeriesUUID, "occurrences": results}
+	c.JSON(status, sendResp)
+	respBytes, _ := json.Marshal(sendResp)
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+}
+
+// get schedule series with occurrences which remain in series
+func (h *_default) GetScheduleSeries(c *gin.Context) {
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	series, err := h.getScheduleSeries(c.Param("series_uuid"))
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromSeriesErr(err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	status, _code, msg := http.StatusOK, 0, "succeed to get schedule series"
+	c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "series_uuid": series.SeriesUUID,
+		"detail": series.Detail, "recurrence": series.Recurrence, "occurrences": series.Occurrences})
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+}
+
+// update occurrence in uri, or occurrences in scope (following, all) shifted as much as occurrence in uri is shifted
+func (h *_default) UpdateScheduleOccurrences(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.UpdateScheduleOccurrencesRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	if receivedReq.EndDate.Unix() < receivedReq.StartDate.Unix() {
+		status, _code, msg := http.StatusBadRequest, code.IntegrityInvalidRequest, "end_date must not be before start_date"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	series, unlock, targets, err := h.lockScheduleOccurrences(c.Param("series_uuid"), c.Param("date"), receivedReq.Scope, uuidClaims.UUID)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromSeriesErr(err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	defer unlock()
+
+	shift := receivedReq.StartDate.Unix() - series.Occurrences[targets[0]].StartDate
+	duration := receivedReq.EndDate.Unix() - receivedReq.StartDate.Unix()
+	results := make([]occurrenceResult, len(targets))
+	forEachConcurrently(len(targets), maxConcurrentScheduleCreation, func(i int) {
+		occurrence := &series.Occurrences[targets[i]]
+		startDate := occurrence.StartDate + shift
+		rpcReq := entity.UpdateScheduleRequest{
+			StartDate: entity.Timestamp(startDate),
+			EndDate:   entity.Timestamp(startDate + duration),
+			Detail:    receivedReq.Detail,
+		}.GenerateGRPCRequest()
+		rpcReq.ScheduleUUID = occurrence.ScheduleUUID
+		rpcReq.Uuid = uuidClaims.UUID
+
+		var rpcResp *scheduleproto.DefaultScheduleResponse
+		err := h.callServiceInSpan(topic.ScheduleServiceName, "UpdateSchedule", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcResp, rpcErr = h.scheduleService.UpdateSchedule(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		results[i] = h.occurrenceResultOf(occurrence.Date, "UpdateSchedule", rpcResp, err, http.StatusOK)
+		results[i].ScheduleUUID = occurrence.ScheduleUUID
+		if results[i].Status == http.StatusOK {
+			occurrence.StartDate, occurrence.EndDate, occurrence.Detail = startDate, startDate+duration, receivedReq.Detail
+		}
+	})
+	if receivedReq.Scope != seriesScopeThis {
+		series.Detail = receivedReq.Detail
+	}
+	h.respondSeriesOperation(c, entry, series, results, "update", string(reqBytes))
+}
+
+// delete occurrence in uri, or occurrences in scope (following, all)
+// deleted occurrence is added in exceptions, and series is truncated if following occurrences are deleted
+func (h *_default) DeleteScheduleOccurrences(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.DeleteScheduleOccurrencesRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	series, unlock, targets, err := h.lockScheduleOccurrences(c.Param("series_uuid"), c.Param("date"), receivedReq.Scope, uuidClaims.UUID)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromSeriesErr(err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	defer unlock()
+
+	results := make([]occurrenceResult, len(targets))
+	forEachConcurrently(len(targets), maxConcurrentScheduleCreation, func(i int) {
+		occurrence := series.Occurrences[targets[i]]
+		rpcReq := new(scheduleproto.DeleteScheduleRequest)
+		rpcReq.Uuid = uuidClaims.UUID
+		rpcReq.ScheduleUUID = occurrence.ScheduleUUID
+
+		var rpcResp *scheduleproto.DefaultScheduleResponse
+		err := h.callServiceInSpan(topic.ScheduleServiceName, "DeleteSchedule", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			var rpcErr error
+			rpcResp, rpcErr = h.scheduleService.DeleteSchedule(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		results[i] = h.occurrenceResultOf(occurrence.Date, "DeleteSchedule", rpcResp, err, http.StatusOK)
+		results[i].ScheduleUUID = occurrence.ScheduleUUID
+	})
+
+	deleted := map[string]bool{}
+	for _, result := range results {
+		if result.Status == http.StatusOK {
+			deleted[result.Date] = true
+		}
+	}
+	var remains []scheduleOccurrence
+	for _, occurrence := range series.Occurrences {
+		if !deleted[occurrence.Date] {
+			remains = append(remains, occurrence)
+		}
+	}
+	series.Occurrences = remains
+
+	switch receivedReq.Scope {
+	case seriesScopeThis:
+		if deleted[c.Param("date")] {
+			series.Recurrence.Exceptions = append(series.Recurrence.Exceptions, c.Param("date"))
+		}
+	case seriesScopeFollowing:
+		if len(remains) != 0 && len(deleted) == len(targets) {
+			series.Recurrence.Until, series.Recurrence.Count = entity.Timestamp(remains[len(remains)-1].StartDate), 0
+		}
+	}
+	h.respondSeriesOperation(c, entry, series, results, "delete", string(reqBytes))
+}
+
+// save changed series (or delete series if no occurrence remains) & response result of each occurrence
+// status is 200 if operation succeeds in any occurrence, so that schedules in redis are deleted once after operation
+func (h *_default) respondSeriesOperation(c *gin.Context, entry *logrus.Entry, series *scheduleSeries, results []occurrenceResult, operation, reqBody string) {
+	succeededNum := 0
+	for _, result := range results {
+		if result.Status == http.StatusOK {
+			succeededNum++
+		}
+	}
+
+	var err error
+	if len(series.Occurrences) == 0 {
+		err = h.redisClient.Del(ctx, scheduleSeriesKey(series.SeriesUUID)).Err()
+	} else {
+		err = h.saveScheduleSeries(*series)
+	}
+
+	switch {
+	case succeededNum == 0:
+		status, _code := results[0].Status, results[0].Code
+		msg := fmt.Sprintf("unable to %s any occurrence of schedule series, msg: %s", operation, results[0].Message)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "occurrences": results})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": reqBody}).Error()
+	case err != nil:
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("schedules are changed, but unable to save schedule series, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "occurrences": results})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": reqBody}).Error()
+	default:
+		status, _code := http.StatusOK, 0
+		msg := fmt.Sprintf("succeed to %s occurrences of schedule series, succeeded num: %d, failed num: %d", operation, succeededNum, len(results)-succeededNum)
+		sendResp := gin.H{"status": status, "code": _code, "message": msg, "occurrences": results}
+		c.JSON(status, sendResp)
+		respBytes, _ := json.Marshal(sendResp)
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": reqBody}).Info()
+	}
+}
+
+var (
+	errScheduleSeriesNotFound     = errors.New("schedule series with that uuid is not found")
+	errScheduleOccurrenceNotFound = errors.New("occurrence of that date is not found in schedule series")
+	errScheduleSeriesForbidden    = errors.New("only writer of schedule series or admin can change it")
+	errScheduleSeriesLocked       = errors.New("schedule series is being changed by another request, please try again later")
+)
+
+func (h *_default) getStatusCodeFromSeriesErr(err error) (status, _code int, msg string) {
+	switch err {
+	case errScheduleSeriesNotFound, errScheduleOccurrenceNotFound:
+		status = http.StatusNotFound
+	case errScheduleSeriesForbidden:
+		status = http.StatusForbidden
+	case errScheduleSeriesLocked:
+		status = http.StatusConflict
+	default:
+		status = http.StatusInternalServerError
+	}
+	return status, 0, err.Error()
+}
+
+// lock series & get it, and return indexes of occurrences in scope from occurrence of date
+// unlock must be called after series is saved, if error is nil
+func (h *_default) lockScheduleOccurrences(seriesUUID, date, scope, userUUID string) (series *scheduleSeries, unlock func(), targets []int, err error) {
+	lockKey, lockValue := scheduleSeriesLockKey(seriesUUID), uuid.New().String()
+	locked, err := h.redisClient.SetNX(ctx, lockKey, lockValue, scheduleSeriesLockTTL).Result()
+	if err != nil {
+		return
+	}
+	if !locked {
+		err = errScheduleSeriesLocked
+		return
+	}
+	stopRenewal := make(chan struct{})
+	go h.renewScheduleSeriesLock(lockKey, lockValue, stopRenewal)
+	unlock = func() {
+		close(stopRenewal)
+		if err := unlockScheduleSeriesScript.Run(ctx, h.redisClient, []string{lockKey}, lockValue).Err(); err != nil {
+			log.Errorf("unable to release lock of schedule series, key: %s, err: %v", lockKey, err)
+		}
+	}
+	defer func() {
+		if err != nil {
+			unlock()
+			unlock = nil
+		}
+	}()
+
+	if series, err = h.getScheduleSeries(seriesUUID); err != nil {
+		return
+	}
+	if series.WriterUUID != userUUID && !adminUUIDRegex.MatchString(userUUID) {
+		err = errScheduleSeriesForbidden
+		return
+	}
+
+	index := sort.Search(len(series.Occurrences), func(i int) bool { return series.Occurrences[i].Date >= date })
+	if index == len(series.Occurrences) || series.Occurrences[index].Date != date {
+		err = errScheduleOccurrenceNotFound
+		return
+	}
+	switch scope {
+	case seriesScopeThis:
+		targets = []int{index}
+	case seriesScopeFollowing:
+		for i := index; i < len(series.Occurrences); i++ {
+			targets = append(targets, i)
+		}
+	case seriesScopeAll:
+		// occurrence in uri is first, so that shift of update is computed with it
+		targets = append(targets, index)
+		for i := range series.Occurrences {
+			if i != index {
+				targets = append(targets, i)
+			}
+		}
+	}
+	return
+}
+
+// renew TTL of series lock periodically until stop is closed, renewal stops if lock is not held by this request any more
+func (h *_default) renewScheduleSeriesLock(lockKey, lockValue string, stop <-chan struct{}) {
+	ticker := time.NewTicker(scheduleSeriesLockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			renewed, err := renewScheduleSeriesLockScript.Run(ctx, h.redisClient, []string{lockKey}, lockValue, scheduleSeriesLockTTL.Milliseconds()).Int()
+			if err != nil {
+				log.Errorf("unable to renew lock of schedule series, key: %s, err: %v", lockKey, err)
+				continue
+			}
+			if renewed != 1 {
+				log.Errorf("lock of schedule series was lost before it is released, key: %s", lockKey)
+				return
+			}
+		}
+	}
+}
+
+func (h *_default) getScheduleSeries(seriesUUID string) (series *scheduleSeries, err error) {
+	value, err := h.redisClient.Get(ctx, scheduleSeriesKey(seriesUUID)).Result()
+	if err == redis.Nil {
+		err = errScheduleSeriesNotFound
+	}
+	if err != nil {
+		return
+	}
+	series = new(scheduleSeries)
+	err = json.Unmarshal([]byte(value), series)
+	return
+}
+
+func (h *_default) saveScheduleSeries(series scheduleSeries) (err error) {
+	seriesBytes, err := json.Marshal(series)
+	if err != nil {
+		return
+	}
+	return h.redisClient.Set(ctx, scheduleSeriesKey(series.SeriesUUID), string(seriesBytes), 0).Err()
+}
+
+// make result of occurrence with response or error of calling schedule service
+func (h *_default) occurrenceResultOf(date, method string, rpcResp *scheduleproto.DefaultScheduleResponse, err error, successStatus int) (result occurrenceResult) {
+	result.Date = date
+	if err != nil {
+		result.Status, result.Code, result.Message = h.getStatusCodeFromCallErr(method, err)
+		return
+	}
+	result.Status, result.Code, result.Message = int(rpcResp.Status), int(rpcResp.Code), rpcResp.Msg
+	if int(rpcResp.Status) == successStatus {
+		result.Message = fmt.Sprintf("succeed to call %s in occurrence", method)
+		result.ScheduleUUID = rpcResp.ScheduleUUID
+	}
+	return
+}
+
+// call fn with index from 0 to n-1 concurrently, at most limit fn run at the same time
+func forEachConcurrently(n, limit int, fn func(i int)) {
+	slots := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// expand start of occurrences with recurrence rule, time of day of start is kept in every occurrence
+// count of rule includes occurrences in exceptions, as COUNT & EXDATE of RRULE
+func expandRecurrence(start time.Time, rule entity.RecurrenceRule, location *time.Location) (starts []time.Time, err error) {
+	interval := rule.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	var until time.Time
+	if rule.Until != 0 {
+		until = time.Unix(rule.Until.Unix(), 0).In(location)
+		until = time.Date(until.Year(), until.Month(), until.Day(), 23, 59, 59, 0, location)
+	}
+	excepted := map[string]bool{}
+	for _, exception := range rule.Exceptions {
+		excepted[exception] = true
+	}
+
+	var candidates []time.Time
+	next := func(candidate time.Time) (done bool) {
+		if !until.IsZero() && candidate.After(until) {
+			return true
+		}
+		if rule.Count != 0 && len(candidates) == rule.Count {
+			return true
+		}
+		candidates = append(candidates, candidate)
+		return false
+	}
+
+	switch rule.Freq {
+	case "weekly":
+		weekdays := []time.Weekday{start.Weekday()}
+		if len(rule.ByDay) != 0 {
+			weekdays = weekdays[:0]
+			for _, day := range rule.ByDay {
+				weekdays = append(weekdays, recurrenceWeekdays[day])
+			}
+		}
+		// weekdays are sorted from Monday, as week start (WKST) is Monday
+		sort.Slice(weekdays, func(i, j int) bool { return (weekdays[i]+6)%7 < (weekdays[j]+6)%7 })
+		weekStart := start.AddDate(0, 0, -int((start.Weekday()+6)%7))
+
+	weekly:
+		for week := 0; len(candidates) <= maxScheduleOccurrences; week += interval {
+			for _, weekday := range weekdays {
+				candidate := weekStart.AddDate(0, 0, week*7+int((weekday+6)%7))
+				if candidate.Before(start) {
+					continue
+				}
+				if next(candidate) {
+					break weekly
+				}
+			}
+		}
+	case "monthly":
+		for month := 0; len(candidates) <= maxScheduleOccurrences && month <= maxScheduleOccurrences*12; month += interval {
+			candidate := time.Date(start.Year(), start.Month()+time.Month(month), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, location)
+			if candidate.Day() != start.Day() {
+				continue // month which doesn't have day of start date is skipped, ex) 31st
+			}
+			if next(candidate) {
+				break
+			}
+		}
+	}
+	if len(candidates) > maxScheduleOccurrences {
+		err = errors.New(fmt.Sprintf("number of occurrences in schedule series must be less than or equal to %d", maxScheduleOccurrences))
+		return
+	}
+
+	for _, candidate := range candidates {
+		if !excepted[candidate.Format("2006-01-02")] {
+			starts = append(starts, candidate)
+		}
+	}
+	if len(starts) == 0 {
+		err = errors.New("there is no occurrence in schedule series")
+	}
+	return
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"gateway/entity"
+	"gateway/tool/redistest"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func testDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, testLocation)
+}
+
+func TestExpandRecurrence(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		start    time.Time
+		rule     entity.RecurrenceRule
+		expected []time.Time
+		isErr    bool
+	}{
+		{
+			name:     "weekly with count",
+			start:    testDate(2020, 9, 2), // Wednesday
+			rule:     entity.RecurrenceRule{Freq: "weekly", Count: 3},
+			expected: []time.Time{testDate(2020, 9, 2), testDate(2020, 9, 9), testDate(2020, 9, 16)},
+		},
+		{
+			name:     "weekly by day skips days before start",
+			start:    testDate(2020, 9, 2),
+			rule:     entity.RecurrenceRule{Freq: "weekly", ByDay: []string{"FR", "MO"}, Count: 4},
+			expected: []time.Time{testDate(2020, 9, 4), testDate(2020, 9, 7), testDate(2020, 9, 11), testDate(2020, 9, 14)},
+		},
+		{
+			name:     "weekly by day with interval starts on sunday",
+			start:    testDate(2020, 9, 6), // Sunday, last day of week starting from Monday
+			rule:     entity.RecurrenceRule{Freq: "weekly", Interval: 2, ByDay: []string{"SU", "MO"}, Count: 3},
+			expected: []time.Time{testDate(2020, 9, 6), testDate(2020, 9, 14), testDate(2020, 9, 20)},
+		},
+		{
+			name:     "weekly until includes last day",
+			start:    testDate(2020, 9, 2),
+			rule:     entity.RecurrenceRule{Freq: "weekly", Until: entity.Timestamp(time.Date(2020, 9, 16, 0, 0, 0, 0, testLocation).Unix())},
+			expected: []time.Time{testDate(2020, 9, 2), testDate(2020, 9, 9), testDate(2020, 9, 16)},
+		},
+		{
+			name:     "exceptions are counted in count",
+			start:    testDate(2020, 9, 2),
+			rule:     entity.RecurrenceRule{Freq: "weekly", Count: 3, Exceptions: []string{"2020-09-09"}},
+			expected: []time.Time{testDate(2020, 9, 2), testDate(2020, 9, 16)},
+		},
+		{
+			name:     "monthly skips month without day",
+			start:    testDate(2021, 1, 31),
+			rule:     entity.RecurrenceRule{Freq: "monthly", Count: 3},
+			expected: []time.Time{testDate(2021, 1, 31), testDate(2021, 3, 31), testDate(2021, 5, 31)},
+		},
+		{
+			name:     "monthly with interval",
+			start:    testDate(2020, 11, 15),
+			rule:     entity.RecurrenceRule{Freq: "monthly", Interval: 3, Until: entity.Timestamp(time.Date(2021, 6, 1, 0, 0, 0, 0, testLocation).Unix())},
+			expected: []time.Time{testDate(2020, 11, 15), testDate(2021, 2, 15), testDate(2021, 5, 15)},
+		},
+		{
+			name:  "too many occurrences",
+			start: testDate(2020, 9, 2),
+			rule:  entity.RecurrenceRule{Freq: "weekly", ByDay: []string{"MO", "WE", "FR"}, Until: entity.Timestamp(time.Date(2022, 1, 1, 0, 0, 0, 0, testLocation).Unix())},
+			isErr: true,
+		},
+		{
+			name:  "every occurrence is excepted",
+			start: testDate(2020, 9, 2),
+			rule:  entity.RecurrenceRule{Freq: "weekly", Count: 1, Exceptions: []string{"2020-09-02"}},
+			isErr: true,
+		},
+	} {
+		starts, err := expandRecurrence(c.start, c.rule, testLocation)
+		if c.isErr {
+			assert.Error(t, err, c.name)
+			continue
+		}
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.expected, starts, c.name)
+	}
+}
+
+func TestLockScheduleOccurrences(t *testing.T) {
+	s := redistest.NewServer()
+	defer s.Close()
+	s.Script(unlockScheduleSeriesScript.Hash(), func(s *redistest.Server, keys, args []string) interface{} {
+		if value, ok := s.Get(keys[0]); ok && value == args[0] {
+			s.Del(keys[0])
+			return 1
+		}
+		return 0
+	})
+	cli := redis.NewClient(&redis.Options{Addr: s.Addr})
+	defer func() { _ = cli.Close() }()
+	h := &_default{redisClient: cli}
+
+	seriesBytes, _ := json.Marshal(scheduleSeries{
+		SeriesUUID: "series-1",
+		WriterUUID: "teacher-123412341234",
+		Occurrences: []scheduleOccurrence{
+			{Date: "2020-09-02"}, {Date: "2020-09-09"}, {Date: "2020-09-16"},
+		},
+	})
+	s.Set(scheduleSeriesKey("series-1"), string(seriesBytes), 0)
+	lockKey := scheduleSeriesLockKey("series-1")
+
+	for _, c := range []struct {
+		scope    string
+		expected []int
+	}{
+		{seriesScopeThis, []int{1}},
+		{seriesScopeFollowing, []int{1, 2}},
+		{seriesScopeAll, []int{1, 0, 2}},
+	} {
+		_, unlock, targets, err := h.lockScheduleOccurrences("series-1", "2020-09-09", c.scope, "teacher-123412341234")
+		assert.NoError(t, err, c.scope)
+		assert.Equal(t, c.expected, targets, c.scope)
+		assert.InDelta(t, scheduleSeriesLockTTL, s.TTL(lockKey), float64(time.Second), c.scope)
+
+		// series can't be locked again until it is unlocked
+		_, _, _, err = h.lockScheduleOccurrences("series-1", "2020-09-09", c.scope, "teacher-123412341234")
+		assert.Equal(t, errScheduleSeriesLocked, err, c.scope)
+
+		unlock()
+		_, locked := s.Get(lockKey)
+		assert.False(t, locked, c.scope)
+	}
+
+	// lock is released if series can't be changed by user or occurrence is not found
+	_, _, _, err := h.lockScheduleOccurrences("series-1", "2020-09-09", seriesScopeThis, "teacher-000000000000")
+	assert.Equal(t, errScheduleSeriesForbidden, err)
+	_, _, _, err = h.lockScheduleOccurrences("series-1", "2020-09-10", seriesScopeThis, "teacher-123412341234")
+	assert.Equal(t, errScheduleOccurrenceNotFound, err)
+	_, locked := s.Get(lockKey)
+	assert.False(t, locked)
+
+	// lock taken by another request after expiry is not released by previous holder
+	_, unlock, _, err := h.lockScheduleOccurrences("series-1", "2020-09-09", seriesScopeThis, "admin-123412341234")
+	assert.NoError(t, err)
+	s.Set(lockKey, "another-request", scheduleSeriesLockTTL)
+	unlock()
+	value, _ := s.Get(lockKey)
+	assert.Equal(t, "another-request", value)
+}
@@ -0,0 +1,35 @@
+// add file in v.1.0.5
+// default_school_time.go is file that declare handler about date computed in time zone of school, such as today & this month
+// date is computed in location of handler instead of client, so that date boundaries are same for every client
+
+package handler
+
+import (
+	"gateway/entity"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// set request entity of GetTimeTable with today in school time zone, must be registered in front of redis responder
+func (h *_default) TodayTimeTableRequestSetter(c *gin.Context) {
+	now := time.Now().In(h.location)
+	c.Set("Request", &entity.GetTimeTableRequest{Year: int32(now.Year()), Month: int32(now.Month()), Day: int32(now.Day())})
+	c.Next()
+}
+
+// set request entity of GetSchedule with this month in school time zone, must be registered in front of redis responder
+func (h *_default) ThisMonthScheduleRequestSetter(c *gin.Context) {
+	now := time.Now().In(h.location)
+	c.Set("Request", &entity.GetScheduleRequest{Year: int32(now.Year()), Month: int32(now.Month())})
+	c.Next()
+}
+
+// get timetable of today in school time zone, request entity is set in TodayTimeTableRequestSetter
+func (h *_default) GetTodayTimeTable(c *gin.Context) {
+	h.GetTimeTable(c)
+}
+
+// get schedules of this month in school time zone, request entity is set in ThisMonthScheduleRequestSetter
+func (h *_default) GetThisMonthSchedule(c *gin.Context) {
+	h.GetSchedule(c)
+}
@@ -0,0 +1,150 @@
+// add file in v.1.0.5
+// default_timetable_range.go is file that declare handler getting timetables of days in range (ex. a week) at once
+// timetable of each day is got concurrently & cached in same redis key as GET /v1/time-tables/years/:year/months/:month/days/:day
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gateway/entity"
+	scheduleproto "gateway/proto/golang/schedule"
+	jwtutil "gateway/tool/jwt"
+	code "gateway/utils/code/golang"
+	topic "gateway/utils/topic/golang"
+	"github.com/gin-gonic/gin"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// max number of days in range & max number of GetTimeTable called at the same time in one request
+const (
+	maxTimeTableDays              = 14
+	maxConcurrentTimeTableQueries = 5
+)
+
+// TTL of timetable cached per day, same as TTL of response set in SetRedisKeyWithResponse
+const timeTableCacheTTL = time.Minute
+
+// keys of periods in timetable response, ex) time1, time2, ... time7
+var timeTablePeriodKeys = []string{"time1", "time2", "time3", "time4", "time5", "time6", "time7"}
+
+func timeTableKey(studentUUID string, date time.Time) string {
+	return fmt.Sprintf("students.%s.timetable.years.%d.months.%d.days.%d", studentUUID, date.Year(), int(date.Month()), date.Day())
+}
+
+// get timetables of days from start date to end date (inclusive), end date is 4 days after start date if it is not set
+// failure of getting timetable of some day is reported in that day, and status is 200 if timetable of any day is got
+func (h *_default) GetTimeTablesInRange(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.GetTimeTablesInRangeRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	startDate, _ := time.ParseInLocation("2006-01-02", receivedReq.StartDate, h.location)
+	endDate := startDate.AddDate(0, 0, 4)
+	if receivedReq.EndDate != "" {
+		endDate, _ = time.ParseInLocation("2006-01-02", receivedReq.EndDate, h.location)
+	}
+	days := int(endDate.Sub(startDate).Hours()/24+0.5) + 1
+	if days < 1 || days > maxTimeTableDays {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("end_date must be after start_date, and number of days in range must be less than or equal to %d", maxTimeTableDays)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	timeTables := make([]gin.H, days)
+	forEachConcurrently(days, maxConcurrentTimeTableQueries, func(i int) {
+		date := startDate.AddDate(0, 0, i)
+		timeTables[i] = h.getTimeTableOfDay(reqID, topSpan, uuidClaims.UUID, date)
+		timeTables[i]["date"] = date.Format("2006-01-02")
+		if timeTables[i]["status"] == http.StatusOK {
+			h.overlayDayOffOnTimeTable(timeTables[i], date)
+		}
+	})
+
+	var failed gin.H
+	succeededNum := 0
+	for _, timeTable := range timeTables {
+		if timeTable["status"] == http.StatusOK {
+			succeededNum++
+		} else if failed == nil {
+			failed = timeTable
+		}
+	}
+
+	if succeededNum == 0 {
+		status, _code, msg := failed["status"].(int), failed["code"].(int), fmt.Sprintf("unable to get time table of any day, msg: %v", failed["message"])
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg, "time_tables": timeTables})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	status, _code := http.StatusOK, 0
+	msg := fmt.Sprintf("succeed to get your time tables in range, succeeded num: %d, failed num: %d", succeededNum, days-succeededNum)
+	sendResp := gin.H{"status": status, "code": _code, "message": msg, "time_tables": timeTables}
+	c.JSON(status, sendResp)
+	respBytes, _ := json.Marshal(sendResp)
+	entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+}
+
+// get timetable of day in redis or from schedule service, returned value has status, code, message & periods of day
+// timetable got from schedule service is set in redis in same format as response of GetTimeTable
+func (h *_default) getTimeTableOfDay(reqID string, topSpan opentracing.Span, studentUUID string, date time.Time) (timeTable gin.H) {
+	key := timeTableKey(studentUUID, date)
+	if value, err := h.redisClient.Get(ctx, key).Result(); err == nil {
+		cached := gin.H{}
+		if err := json.Unmarshal([]byte(value), &cached); err == nil {
+			timeTable = gin.H{"status": http.StatusOK, "code": 0, "message": cached["message"]}
+			for _, period := range timeTablePeriodKeys {
+				timeTable[period] = cached[period]
+			}
+			return
+		}
+	}
+
+	rpcReq := entity.GetTimeTableRequest{Year: int32(date.Year()), Month: int32(date.Month()), Day: int32(date.Day())}.GenerateGRPCRequest()
+	rpcReq.Uuid = studentUUID
+	var rpcResp *scheduleproto.GetTimeTableResponse
+	err := h.callServiceInSpan(topic.ScheduleServiceName, "GetTimeTable", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+		var rpcErr error
+		rpcResp, rpcErr = h.scheduleService.GetTimeTable(ctx, rpcReq, opts...)
+		return int(rpcResp.GetStatus()), rpcErr
+	})
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetTimeTable", err)
+		return gin.H{"status": status, "code": _code, "message": msg}
+	}
+	if rpcResp.Status != http.StatusOK {
+		return gin.H{"status": int(rpcResp.Status), "code": int(rpcResp.Code), "message": rpcResp.Msg}
+	}
+
+	timeTable = gin.H{"status": http.StatusOK, "code": 0, "message": "succeed to get your time table in that week number",
+		"time1": rpcResp.Time1, "time2": rpcResp.Time2, "time3": rpcResp.Time3, "time4": rpcResp.Time4,
+		"time5": rpcResp.Time5, "time6": rpcResp.Time6, "time7": rpcResp.Time7}
+	if respBytes, err := json.Marshal(timeTable); err == nil {
+		h.redisClient.Set(ctx, key, string(respBytes), timeTableCacheTTL)
+	}
+	return
+}
@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"gateway/consul"
 	consulagent "gateway/consul/agent"
 	jwtutil "gateway/tool/jwt"
 	code "gateway/utils/code/golang"
 	respcode "gateway/utils/code/golang"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/opentracing/opentracing-go"
 	"net/http"
 	"strings"
 )
@@ -78,3 +82,33 @@ func (h *_default) getStatusCodeFromConsulErr(err error) (status, _code int, msg
 	}
 	return
 }
+
+// this method is to get error to report as result of service node call, 5xx status in response is also treated as failure
+// add in v.1.0.5
+func nodeCallErr(rpcErr error, status int) error {
+	if rpcErr == nil && status >= http.StatusInternalServerError {
+		return errors.New(fmt.Sprintf("service node responses with %d status", status))
+	}
+	return rpcErr
+}
+
+// this method is to get next service node with routing information of request, such as header & user type
+// routing rule & version of selected node are set in tag of top span (add in v.1.0.5)
+func (h *_default) getNextServiceNode(c *gin.Context, service consul.ServiceName) (*registry.Node, error) {
+	route := consul.Route{Header: c.Request.Header}
+	if inAdvanceClaims, ok := c.Get("Claims"); ok {
+		uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+		route.UserUUID, route.UserType = uuidClaims.UUID, uuidClaims.Type
+	}
+
+	selectedNode, rule, err := h.consulAgent.GetNextServiceNodeWithRoute(service, route)
+	if err != nil {
+		return nil, err
+	}
+
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	if topSpan, ok := inAdvanceTopSpan.(opentracing.Span); ok {
+		topSpan.SetTag("routing.rule", rule).SetTag("routing.version", selectedNode.Metadata["Version"])
+	}
+	return selectedNode, nil
+}
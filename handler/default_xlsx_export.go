@@ -0,0 +1,272 @@
+// add file in v.1.0.5
+// default_xlsx_export.go is file that declare method exporting data of service to xlsx file
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gateway/entity"
+	authproto "gateway/proto/golang/auth"
+	jwtutil "gateway/tool/jwt"
+	code "gateway/utils/code/golang"
+	topic "gateway/utils/topic/golang"
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	studentInformsChunk = 100 // number of student uuids sent to auth service at once
+	excelDateTimeFormat = "yyyy-mm-dd hh:mm"
+	excelContentType    = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// header & width of columns in sheet of outing export
+var outingExportColumns = []struct {
+	header string
+	width  float64
+}{
+	{"학번", 8}, {"이름", 10}, {"장소", 24}, {"사유", 30}, {"외출 시작", 18}, {"외출 종료", 18},
+	{"상태", 10}, {"구분", 10}, {"지각", 6},
+}
+
+// export outings starting in date range with filter to xlsx file, one sheet per grade
+func (h *_default) ExportOutingsToExcel(c *gin.Context) {
+	reqID := c.GetHeader("X-Request-Id")
+
+	// get top span from middleware
+	inAdvanceTopSpan, _ := c.Get("TopSpan")
+	topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+	// get log entry from middleware
+	inAdvanceEntry, _ := c.Get("RequestLogEntry")
+	entry, _ := inAdvanceEntry.(*logrus.Entry)
+
+	// get token claim from middleware
+	inAdvanceClaims, _ := c.Get("Claims")
+	uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+	entry = entry.WithField("user_uuid", uuidClaims.UUID)
+
+	// get bound request entry from middleware
+	inAdvanceReq, _ := c.Get("Request")
+	receivedReq, _ := inAdvanceReq.(*entity.ExportOutingsToExcelRequest)
+	reqBytes, _ := json.Marshal(receivedReq)
+
+	var err error
+	if !adminUUIDRegex.MatchString(uuidClaims.UUID) && !teacherUUIDRegex.MatchString(uuidClaims.UUID) {
+		status, _code, msg := http.StatusForbidden, 0, "only admin & teacher can export outings to excel"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	// narrow filter to scope of teacher, same as GET /v1/outings/with-filter
+	if receivedReq.Grade, receivedReq.Group, err = h.scopeOutingFilter(reqID, topSpan, uuidClaims.UUID, receivedReq.Grade, receivedReq.Group); err != nil {
+		status, _code, msg := h.getStatusCodeFromScopeErr(err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	startDate, _ := time.ParseInLocation("2006-01-02", receivedReq.StartDate, h.location)
+	endDate, _ := time.ParseInLocation("2006-01-02", receivedReq.EndDate, h.location)
+	if endDate.Before(startDate) {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := "end_date must not be before start_date"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	startTime, endTime := startDate.Unix(), endDate.AddDate(0, 0, 1).Unix()
+
+	// page through outings with filter & gather outings starting in date range
+	outings, truncated, rpcResp, err := h.scanOutings(reqID, topSpan, uuidClaims.UUID, receivedReq.GenerateGRPCRequest, startTime, endTime)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetOutingWithFilter", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+	if rpcResp.Status != http.StatusOK {
+		c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
+		entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "request": string(reqBytes)}).Info()
+		return
+	}
+	if truncated {
+		status, _code := http.StatusBadRequest, code.IntegrityInvalidRequest
+		msg := fmt.Sprintf("too many outings to export (more than %d), please narrow filter such as status, grade, group & floor", maxScanOutings)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+		return
+	}
+
+	names, err := h.getStudentNames(reqID, topSpan, uuidClaims.UUID, outings)
+	if err != nil {
+		status, _code, msg := h.getStatusCodeFromCallErr("GetStudentInformsWithUUIDs", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	excel, err := newOutingExportExcel(outings, names, h.location)
+	if err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to write outings in excel file, err: %v", err)
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		return
+	}
+
+	fileName := fmt.Sprintf("outings_%s_%s.xlsx", receivedReq.StartDate, receivedReq.EndDate)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Content-Type", excelContentType)
+	c.Status(http.StatusOK)
+	if err := excel.Write(c.Writer); err != nil {
+		entry.WithFields(logrus.Fields{"status": http.StatusOK, "code": 0, "message": fmt.Sprintf("unable to stream excel file, err: %v", err), "request": string(reqBytes)}).Error()
+		return
+	}
+
+	msg := fmt.Sprintf("succeed to export outings to excel file, outing num: %d", len(outings))
+	entry.WithFields(logrus.Fields{"status": http.StatusOK, "code": 0, "message": msg, "request": string(reqBytes)}).Info()
+}
+
+// get name of students in outings with GetStudentInformsWithUUIDs, name in outing is used if student is not found
+func (h *_default) getStudentNames(reqID string, topSpan opentracing.Span, uuid string, outings []scannedOuting) (names map[string]string, err error) {
+	names = map[string]string{}
+	var studentUUIDs []string
+	for _, outing := range outings {
+		if _, ok := names[outing.studentUUID]; !ok && outing.studentUUID != "" {
+			names[outing.studentUUID] = outing.name
+			studentUUIDs = append(studentUUIDs, outing.studentUUID)
+		}
+	}
+
+	for i := 0; i < len(studentUUIDs); i += studentInformsChunk {
+		chunk := studentUUIDs[i:]
+		if len(chunk) > studentInformsChunk {
+			chunk = chunk[:studentInformsChunk]
+		}
+
+		var rpcResp *authproto.GetStudentInformsWithUUIDsResponse
+		err = h.callServiceInSpan(topic.AuthServiceName, "GetStudentInformsWithUUIDs", reqID, topSpan, func(ctx context.Context, opts ...client.CallOption) (int, error) {
+			rpcReq := entity.GetStudentInformsWithUUIDsRequest{StudentUUIDs: chunk}.GenerateGRPCRequest()
+			rpcReq.UUID = uuid
+			var rpcErr error
+			rpcResp, rpcErr = h.authService.GetStudentInformsWithUUIDs(ctx, rpcReq, opts...)
+			return int(rpcResp.GetStatus()), rpcErr
+		})
+		if err == nil && rpcResp.Status != http.StatusOK {
+			err = errors.New(fmt.Sprintf("GetStudentInformsWithUUIDs responses with %d status, msg: %s", rpcResp.Status, rpcResp.Message))
+		}
+		if err != nil {
+			return
+		}
+
+		for _, inform := range rpcResp.StudentInforms {
+			names[inform.StudentUUID] = inform.Name
+		}
+	}
+	return
+}
+
+// create excel file writing outings in sheet per grade, sorted by student number & start time
+func newOutingExportExcel(outings []scannedOuting, names map[string]string, location *time.Location) (excel *excelize.File, err error) {
+	sort.SliceStable(outings, func(i, j int) bool {
+		if ni, nj := outings[i].studentNumber(), outings[j].studentNumber(); ni != nj {
+			return ni < nj
+		}
+		return outings[i].startTime < outings[j].startTime
+	})
+
+	excel = excelize.NewFile()
+	headerStyle, err := excel.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#DDEBF7"}},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	if err != nil {
+		return
+	}
+	dateTimeFormat := excelDateTimeFormat
+	dateTimeStyle, err := excel.NewStyle(&excelize.Style{CustomNumFmt: &dateTimeFormat})
+	if err != nil {
+		return
+	}
+
+	rowsPerSheet := map[string]int{}
+	for _, outing := range outings {
+		sheet := fmt.Sprintf("%d학년", outing.grade)
+		if _, ok := rowsPerSheet[sheet]; !ok {
+			// rename default sheet for first grade, create new sheet for others
+			if len(rowsPerSheet) == 0 {
+				excel.SetSheetName("Sheet1", sheet)
+			} else {
+				excel.NewSheet(sheet)
+			}
+			if err = writeOutingExportHeader(excel, sheet, headerStyle); err != nil {
+				return
+			}
+			rowsPerSheet[sheet] = 1
+		}
+		rowsPerSheet[sheet]++
+		row := rowsPerSheet[sheet]
+
+		name := names[outing.studentUUID]
+		if name == "" {
+			name = outing.name
+		}
+		late := ""
+		if outing.isLate {
+			late = "O"
+		}
+		values := []interface{}{
+			outing.studentNumber(), name, outing.place, outing.reason,
+			wallClock(outing.startTime, location), wallClock(outing.endTime, location),
+			outing.status, outing.situation, late,
+		}
+		if err = excel.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &values); err != nil {
+			return
+		}
+		if err = excel.SetCellStyle(sheet, fmt.Sprintf("E%d", row), fmt.Sprintf("F%d", row), dateTimeStyle); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// write header with style, width of columns & frozen pane in sheet
+func writeOutingExportHeader(excel *excelize.File, sheet string, headerStyle int) (err error) {
+	for i, column := range outingExportColumns {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err = excel.SetCellValue(sheet, fmt.Sprintf("%s1", col), column.header); err != nil {
+			return
+		}
+		if err = excel.SetColWidth(sheet, col, col, column.width); err != nil {
+			return
+		}
+	}
+	lastCol, _ := excelize.ColumnNumberToName(len(outingExportColumns))
+	if err = excel.SetCellStyle(sheet, "A1", fmt.Sprintf("%s1", lastCol), headerStyle); err != nil {
+		return
+	}
+	return excel.SetPanes(sheet, `{"freeze":true,"split":false,"x_split":0,"y_split":1,"top_left_cell":"A2","active_pane":"bottomLeft"}`)
+}
+
+// return student number like 2113 (grade 2, group 1, number 13)
+func (o scannedOuting) studentNumber() string {
+	return fmt.Sprintf("%d%d%02d", o.grade, o.group, o.number)
+}
+
+// convert unix time to wall clock of school time zone in UTC location, because excelize accepts only time in UTC
+func wallClock(unix int64, location *time.Location) time.Time {
+	t := time.Unix(unix, 0).In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
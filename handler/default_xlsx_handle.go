@@ -13,6 +13,7 @@ import (
 	code "gateway/utils/code/golang"
 	topic "gateway/utils/topic/golang"
 	"github.com/360EntSecGroup-Skylar/excelize/v2"
+	"github.com/eapache/go-resiliency/breaker"
 	"github.com/gin-gonic/gin"
 	"github.com/micro/go-micro/v2/client"
 	"github.com/micro/go-micro/v2/errors"
@@ -212,7 +213,7 @@ func (h *_default) AddUnsignedStudentsFromExcel(c *gin.Context) {
 	// student 0개면 빠꾸
 
 	// get service node
-	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.AuthServiceName)
+	selectedNode, err := h.getNextServiceNode(c, topic.AuthServiceName)
 	if err != nil {
 		status, _code, msg := h.getStatusCodeFromConsulErr(err)
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
@@ -221,19 +222,31 @@ func (h *_default) AddUnsignedStudentsFromExcel(c *gin.Context) {
 	}
 	entry = entry.WithField("SelectedNode", *selectedNode)
 
-	// send gRPC request
-	authSrvSpan := h.tracer.StartSpan("AddUnsignedStudents", opentracing.ChildOf(topSpan.Context()))
-	ctxForReq := context.Background()
-	ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
-	ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
-	rpcReq := &authproto.AddUnsignedStudentsRequest{
-		UUID:     uuidClaims.UUID,
-		Students: studentsForReq,
+	h.mutex.Lock()
+	if _, ok := h.breakers[selectedNode.Id]; !ok {
+		h.breakers[selectedNode.Id] = newNodeBreaker(h.BreakerCfg)
 	}
-	callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
-	rpcResp, rpcErr := h.authService.AddUnsignedStudents(ctxForReq, rpcReq, callOpts...)
-	authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
-	authSrvSpan.Finish()
+	h.mutex.Unlock()
+
+	// send gRPC request in bulkhead & circuit breaker with tracking call to node (change in v.1.0.5)
+	var rpcResp *authproto.AddUnsignedStudentsResponse
+	err = h.runInBulkhead(topic.AuthServiceName, "AddUnsignedStudents", h.breakers[selectedNode.Id], func() (rpcErr error) {
+		authSrvSpan := h.tracer.StartSpan("AddUnsignedStudents", opentracing.ChildOf(topSpan.Context()))
+		ctxForReq := context.Background()
+		ctxForReq = metadata.Set(ctxForReq, "X-Request-Id", reqID)
+		ctxForReq = metadata.Set(ctxForReq, "Span-Context", authSrvSpan.Context().(jaeger.SpanContext).String())
+		rpcReq := &authproto.AddUnsignedStudentsRequest{
+			UUID:     uuidClaims.UUID,
+			Students: studentsForReq,
+		}
+		callOpts := append(h.DefaultCallOpts, client.WithAddress(selectedNode.Address))
+		rpcDone := h.consulAgent.TrackServiceNodeCall(topic.AuthServiceName, selectedNode)
+		rpcResp, rpcErr = h.authService.AddUnsignedStudents(ctxForReq, rpcReq, callOpts...)
+		rpcDone(nodeCallErr(rpcErr, int(rpcResp.GetStatus())))
+		authSrvSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("request", rpcReq), log.Object("response", rpcResp), log.Error(rpcErr))
+		authSrvSpan.Finish()
+		return
+	})
 
 	switch rpcErr := err.(type) {
 	case nil:
@@ -252,8 +265,18 @@ func (h *_default) AddUnsignedStudentsFromExcel(c *gin.Context) {
 		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
 		return
 	default:
-		status, _code := http.StatusInternalServerError, 0
-		msg := fmt.Sprintf("AddUnsignedStudents returns unexpected type of error, err: %s", rpcErr.Error())
+		status, _code, msg := 0, 0, ""
+		switch rpcErr {
+		case ErrBulkheadFull:
+			status, _code = http.StatusServiceUnavailable, bulkheadFullCode
+			msg = "too many in-flight requests to AddUnsignedStudents service, please try again later"
+		case breaker.ErrBreakerOpen:
+			status, _code = http.StatusServiceUnavailable, code.CircuitBreakerOpen
+			msg = fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
+		default:
+			status, _code = http.StatusInternalServerError, 0
+			msg = fmt.Sprintf("AddUnsignedStudents returns unexpected type of error, err: %s", rpcErr.Error())
+		}
 		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
 		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
 		return
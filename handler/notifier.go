@@ -0,0 +1,68 @@
+// add file in v.1.0.5
+// notifier.go is file that declare notifier sending notification to user, such as teacher or parent
+// notifier can be changed with Notifier field setter, and log notifier is used if not set
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	log "github.com/micro/go-micro/v2/logger"
+)
+
+// Notification is message sent to user
+type Notification struct {
+	Kind         string // kind of notification, ex) outing.overdue
+	ReceiverUUID string
+	PhoneNumber  string
+	Message      string
+}
+
+// Notifier send notification to user, implementation must be safe for concurrent use
+type Notifier interface {
+	Notify(notification Notification) error
+}
+
+// logNotifier only write notification in log, used in local or if notifier is not set
+type logNotifier struct{}
+
+func LogNotifier() Notifier {
+	return logNotifier{}
+}
+
+func (logNotifier) Notify(n Notification) error {
+	log.Infof("notification!, kind: %s, receiver: %s, phone number: %s, message: %s", n.Kind, n.ReceiverUUID, n.PhoneNumber, n.Message)
+	return nil
+}
+
+// snsNotifier send notification in SMS to phone number of receiver with aws sns
+type snsNotifier struct {
+	client *sns.SNS
+}
+
+func SNSNotifier(awsSession *session.Session) Notifier {
+	return &snsNotifier{client: sns.New(awsSession)}
+}
+
+func (s *snsNotifier) Notify(n Notification) (err error) {
+	if n.PhoneNumber == "" {
+		err = errors.New(fmt.Sprintf("phone number of receiver is empty, receiver: %s", n.ReceiverUUID))
+		return
+	}
+
+	// phone number is saved like 01012345678, so change to E.164 format
+	phoneNumber := n.PhoneNumber
+	if len(phoneNumber) > 0 && phoneNumber[0] == '0' {
+		phoneNumber = "+82" + phoneNumber[1:]
+	}
+	if _, err = s.client.Publish(&sns.PublishInput{
+		Message:     aws.String(n.Message),
+		PhoneNumber: aws.String(phoneNumber),
+	}); err != nil {
+		err = errors.New(fmt.Sprintf("unable to publish SMS in aws sns, receiver: %s, err: %v", n.ReceiverUUID, err))
+	}
+	return
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"gateway/consul"
 	consulagent "gateway/consul/agent"
@@ -35,6 +36,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -42,19 +45,38 @@ import (
 import _ "gateway/tool/profiling"
 
 func main() {
-	// create consul connection & consul agent
-	consulCfg := api.DefaultConfig()
-	consulCfg.Address = env.GetAndFatalIfNotExits("CONSUL_ADDRESS") // change how to get env from local in v.1.0.2
-	consulCli, err := api.NewClient(consulCfg)
-	if err != nil {
-		log.Fatalf("unable to connect consul agent, err: %v", err)
-	}
-	consulAgent := consulagent.Default(
+	// create consul agent with mode in env, file mode is used for local development without consul (add in v.1.0.5)
+	agentSetters := []consulagent.FieldSetter{
 		consulagent.Strategy(selector.RoundRobin),
-		consulagent.Client(consulCli),
+		consulagent.RoutingRulesKey("routing/gateway/rules"),                             // add in v.1.0.5
 		consulagent.Services([]consul.ServiceName{topic.AuthServiceName, topic.ClubServiceName,  // add in v.1.0.2
 			topic.OutingServiceName, topic.ScheduleServiceName, topic.AnnouncementServiceName}),
-	)
+	}
+	// load balancing strategy per service, ex) DMS.SMS.v1.service.auth=least-outstanding,DMS.SMS.v1.service.outing=latency-ewma (add in v.1.0.5)
+	defaultServiceStrategies := fmt.Sprintf("%s=least-outstanding,%s=latency-ewma", topic.AuthServiceName, topic.OutingServiceName)
+	serviceStrategies, err := consulagent.ServiceStrategiesFromString(env.GetOrDefault("SERVICE_STRATEGIES", defaultServiceStrategies))
+	if err != nil {
+		log.Fatalf("unable to parse SERVICE_STRATEGIES in environment variable, err: %v", err)
+	}
+	for service, strategy := range serviceStrategies {
+		agentSetters = append(agentSetters, consulagent.ServiceStrategy(service, strategy))
+	}
+	var consulAgent consul.Agent
+	switch mode := env.GetOrDefault("CONSUL_AGENT_MODE", "consul"); mode {
+	case "consul":
+		// create consul connection & consul agent
+		consulCfg := api.DefaultConfig()
+		consulCfg.Address = env.GetAndFatalIfNotExits("CONSUL_ADDRESS") // change how to get env from local in v.1.0.2
+		consulCli, err := api.NewClient(consulCfg)
+		if err != nil {
+			log.Fatalf("unable to connect consul agent, err: %v", err)
+		}
+		consulAgent = consulagent.Default(append(agentSetters, consulagent.Client(consulCli))...)
+	case "file":
+		consulAgent = consulagent.File(env.GetAndFatalIfNotExits("CONSUL_AGENT_FILE"), agentSetters...)
+	default:
+		log.Fatalf("undefined consul agent mode, mode: %s", mode)
+	}
 
 	// create jaeger connection
 	jaegerAddr := env.GetAndFatalIfNotExits("JAEGER_ADDRESS")
@@ -103,6 +125,114 @@ func main() {
 	scheduleSrvCli := scheduleproto.NewScheduleSrv("schedule", gRPCCli)
 	announcementSrvCli := announcementproto.NewAnnouncementSrv("announcement", gRPCCli)
 
+	// parse bulkhead config per service or method, key is "{service}" or "{service}/{method}" (add in v.1.0.5)
+	bulkheadCfgs, err := handler.ParseBulkheadConfigs([]byte(env.GetOrDefault("BULKHEAD_CONFIGS", "{}")))
+	if err != nil {
+		log.Fatalf("unable to parse bulkhead configs in environment variable, err: %v", err)
+	}
+
+	// redis topic to fan out outing event to outing streams in all replica (add in v.1.0.5)
+	outingEventTopic := env.GetAndFatalIfNotExits("OUTING_EVENT_TOPIC")
+
+	// secret key to sign QR code of outing card & outing status certifiable with QR code (add in v.1.0.5)
+	outingCardSecret := env.GetAndFatalIfNotExits("OUTING_CARD_SECRET_KEY")
+	certifiableStatuses := strings.Split(env.GetOrDefault("OUTING_CARD_CERTIFIABLE_STATUSES", handler.OutingStatusEnded), ",")
+
+	// TTL policy of outing statistics cached in redis (add in v.1.0.5)
+	var statisticsTTL handler.StatisticsTTLConfig
+	if statisticsTTL.Current, err = time.ParseDuration(env.GetOrDefault("OUTING_STATISTICS_CURRENT_TTL", "1m")); err != nil {
+		log.Fatalf("unable to parse OUTING_STATISTICS_CURRENT_TTL in environment variable, err: %v", err)
+	}
+	if statisticsTTL.Past, err = time.ParseDuration(env.GetOrDefault("OUTING_STATISTICS_PAST_TTL", "24h")); err != nil {
+		log.Fatalf("unable to parse OUTING_STATISTICS_PAST_TTL in environment variable, err: %v", err)
+	}
+
+	// notifier & config of job detecting overdue outings, job doesn't run if worker uuid is not set (add in v.1.0.5)
+	notifier := handler.LogNotifier()
+	if env.GetOrDefault("NOTIFIER", "log") == "sns" {
+		notifier = handler.SNSNotifier(awsSession)
+	}
+	overdueCfg := handler.OverdueConfig{
+		WorkerUUID: env.GetOrDefault("OVERDUE_WORKER_UUID", ""),
+		OutStatus:  env.GetOrDefault("OVERDUE_OUTING_STATUS", handler.OutingStatusOut),
+	}
+	if overdueCfg.Interval, err = time.ParseDuration(env.GetOrDefault("OVERDUE_CHECK_INTERVAL", "1m")); err != nil {
+		log.Fatalf("unable to parse OVERDUE_CHECK_INTERVAL in environment variable, err: %v", err)
+	}
+	if overdueCfg.Grace, err = time.ParseDuration(env.GetOrDefault("OVERDUE_GRACE", "10m")); err != nil {
+		log.Fatalf("unable to parse OVERDUE_GRACE in environment variable, err: %v", err)
+	}
+
+	// secret key to sign parent approval token issued with confirm code & TTL of the token (add in v.1.0.5)
+	approvalSecret := env.GetAndFatalIfNotExits("PARENT_APPROVAL_SECRET_KEY")
+	approvalWorkerUUID := env.GetAndFatalIfNotExits("PARENT_APPROVAL_WORKER_UUID") // uuid of admin resolving parent of outing
+	approvalTokenTTL, err := time.ParseDuration(env.GetOrDefault("PARENT_APPROVAL_TOKEN_TTL", "24h"))
+	if err != nil {
+		log.Fatalf("unable to parse PARENT_APPROVAL_TOKEN_TTL in environment variable, err: %v", err)
+	}
+
+	// source of outing policy, policy is loaded from file if path is set, or from consul KV (add in v.1.0.5)
+	outingPolicySource := handler.OutingPolicyFromKV(consulAgent, env.GetOrDefault("OUTING_POLICY_KV_KEY", "outing/policy"))
+	if policyFile := env.GetOrDefault("OUTING_POLICY_FILE", ""); policyFile != "" {
+		outingPolicySource = handler.OutingPolicyFromFile(policyFile)
+	}
+	policyReloadInterval, err := time.ParseDuration(env.GetOrDefault("OUTING_POLICY_RELOAD_INTERVAL", "1m"))
+	if err != nil {
+		log.Fatalf("unable to parse OUTING_POLICY_RELOAD_INTERVAL in environment variable, err: %v", err)
+	}
+
+	// config of escalation workflow of emergency outing, admin contacts are set like "{uuid}:{phone number},..." (add in v.1.0.5)
+	escalationCfg := handler.EscalationConfig{
+		WorkerUUID:    env.GetOrDefault("EMERGENCY_WORKER_UUID", ""),
+		AdminContacts: map[string]string{},
+	}
+	if escalationCfg.Timeout, err = time.ParseDuration(env.GetOrDefault("EMERGENCY_ESCALATION_TIMEOUT", "10m")); err != nil {
+		log.Fatalf("unable to parse EMERGENCY_ESCALATION_TIMEOUT in environment variable, err: %v", err)
+	}
+	if escalationCfg.Interval, err = time.ParseDuration(env.GetOrDefault("EMERGENCY_ESCALATION_INTERVAL", "30s")); err != nil {
+		log.Fatalf("unable to parse EMERGENCY_ESCALATION_INTERVAL in environment variable, err: %v", err)
+	}
+	if contacts := env.GetOrDefault("EMERGENCY_ADMIN_CONTACTS", ""); contacts != "" {
+		for _, contact := range strings.Split(contacts, ",") {
+			if kv := strings.SplitN(contact, ":", 2); len(kv) == 2 {
+				escalationCfg.AdminContacts[kv[0]] = kv[1]
+			} else {
+				log.Fatalf("invalid format of EMERGENCY_ADMIN_CONTACTS in environment variable, contact: %s", contact)
+			}
+		}
+	}
+
+	// TTL of class of teacher cached in redis, which is used to narrow scope of outing filter (add in v.1.0.5)
+	teacherClassTTL, err := time.ParseDuration(env.GetOrDefault("TEACHER_CLASS_CACHE_TTL", "10m"))
+	if err != nil {
+		log.Fatalf("unable to parse TEACHER_CLASS_CACHE_TTL in environment variable, err: %v", err)
+	}
+
+	// time zone of school, used to compute date such as today & to format local time in response (add in v.1.0.5)
+	schoolLocation, err := time.LoadLocation(env.GetOrDefault("SCHOOL_TIME_ZONE", "Asia/Seoul"))
+	if err != nil {
+		log.Fatalf("unable to load SCHOOL_TIME_ZONE in environment variable, err: %v", err)
+	}
+
+	// range of months aggregated in iCalendar feed of schedules & TTL of feed cached in redis (add in v.1.0.5)
+	feedCfg := handler.ScheduleFeedConfig{}
+	if feedCfg.PastMonths, err = strconv.Atoi(env.GetOrDefault("SCHEDULE_FEED_PAST_MONTHS", "1")); err != nil {
+		log.Fatalf("unable to parse SCHEDULE_FEED_PAST_MONTHS in environment variable, err: %v", err)
+	}
+	if feedCfg.FutureMonths, err = strconv.Atoi(env.GetOrDefault("SCHEDULE_FEED_FUTURE_MONTHS", "6")); err != nil {
+		log.Fatalf("unable to parse SCHEDULE_FEED_FUTURE_MONTHS in environment variable, err: %v", err)
+	}
+	if feedCfg.CacheTTL, err = time.ParseDuration(env.GetOrDefault("SCHEDULE_FEED_CACHE_TTL", "1h")); err != nil {
+		log.Fatalf("unable to parse SCHEDULE_FEED_CACHE_TTL in environment variable, err: %v", err)
+	}
+
+	// path of holiday file bundled in image & interval to reload it, file can be replaced without deployment (add in v.1.0.5)
+	holidayFile := env.GetOrDefault("HOLIDAY_FILE", "/etc/gateway/holidays.yaml")
+	holidayReloadInterval, err := time.ParseDuration(env.GetOrDefault("HOLIDAY_RELOAD_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("unable to parse HOLIDAY_RELOAD_INTERVAL in environment variable, err: %v", err)
+	}
+
 	// create http request & event handler
 	defaultHandler := handler.Default(
 		handler.ConsulAgent(consulAgent),
@@ -110,12 +240,25 @@ func main() {
 		handler.Tracer(apiTracer),
 		handler.AWSSession(awsSession),
 		handler.RedisClient(redisCli),
-		handler.Location(time.UTC),
+		handler.Location(schoolLocation), // change in v.1.0.5
 		handler.AuthService(authSrvCli),
 		handler.ClubService(clubSrvCli),
 		handler.OutingService(outingSrvCli),
 		handler.ScheduleService(scheduleSrvCli),
 		handler.AnnouncementService(announcementSrvCli),
+		handler.BulkheadConfigs(bulkheadCfgs), // add in v.1.0.5
+		handler.OutingEventTopic(outingEventTopic), // add in v.1.0.5
+		handler.OutingCardSecret(outingCardSecret), // add in v.1.0.5
+		handler.CertifiableOutingStatuses(certifiableStatuses), // add in v.1.0.5
+		handler.OutingStatisticsTTL(statisticsTTL), // add in v.1.0.5
+		handler.UserNotifier(notifier), // add in v.1.0.5
+		handler.OverdueDetector(overdueCfg), // add in v.1.0.5
+		handler.ParentApprovalToken(approvalSecret, approvalWorkerUUID, approvalTokenTTL), // add in v.1.0.5
+		handler.OutingPolicy(outingPolicySource, policyReloadInterval), // add in v.1.0.5
+		handler.EmergencyEscalation(escalationCfg), // add in v.1.0.5
+		handler.TeacherClassCacheTTL(teacherClassTTL), // add in v.1.0.5
+		handler.ScheduleFeed(feedCfg), // add in v.1.0.5
+		handler.HolidayCalendarFile(holidayFile, holidayReloadInterval), // add in v.1.0.5
 	)
 
 	// create subscriber & register aws sqs, redis listener (add in v.1.0.2)
@@ -135,6 +278,7 @@ func main() {
 		//}),
 		subscriber.RedisListener(redisDelTopic, defaultHandler.DeleteAssociatedRedisKey, 5), // add in v.1.0.3
 		subscriber.RedisListener(redisSetTopic, defaultHandler.SetRedisKeyWithResponse, 5), // add in v.1.0.4
+		subscriber.RedisListener(outingEventTopic, defaultHandler.BroadcastOutingEvent, 20), // add in v.1.0.5
 	)
 
 	// create logger & add hooks
@@ -148,6 +292,7 @@ func main() {
 	announcementLogger := customlogrus.New("/usr/share/filebeat/log/dms-sms/announcement.log", logrus.Fields{"service": "announcement"})
 	openApiLogger := customlogrus.New("/usr/share/filebeat/log/dms-sms/open-api.log", logrus.Fields{"service": "open-api"})
 	excelApiLogger := customlogrus.New("/usr/share/filebeat/log/dms-sms/excel-api.log", logrus.Fields{"service": "excel-api"})
+	adminApiLogger := customlogrus.New("/usr/share/filebeat/log/dms-sms/admin-api.log", logrus.Fields{"service": "admin-api"}) // add in v.1.0.5
 
 	// create custom router & register function to execute before run
 	gin.SetMode(gin.ReleaseMode)
@@ -155,7 +300,12 @@ func main() {
 	globalRouter.RegisterBeforeRun(
 		defaultHandler.ConsulChangeEventPublisher(),
 		consulAgent.ChangeAllServiceNodes,
+		consulAgent.WatchAllServiceNodes, // add in v.1.0.5
 		defaultSubscriber.StartListening,
+		defaultHandler.OverdueOutingDetector(), // add in v.1.0.5
+		defaultHandler.OutingPolicyLoader(), // add in v.1.0.5
+		defaultHandler.EmergencyOutingEscalator(), // add in v.1.0.5
+		defaultHandler.HolidayLoader(), // add in v.1.0.5
 	)
 
 	// routing ping & pong API
@@ -163,10 +313,8 @@ func main() {
 	healthCheckRouter.GET("/ping", func(c *gin.Context) { // add in v.1.0.2
 		c.JSON(http.StatusOK, "pong")
 	})
-
-	// routing API to use in consul watch
-	consulWatchRouter := globalRouter.Group("/")
-	consulWatchRouter.POST("/events/types/consul-change", defaultHandler.PublishConsulChangeEvent) // add in v.1.0.2
+	healthCheckRouter.GET("/healthz", defaultHandler.Healthz)                    // add in v.1.0.5
+	healthCheckRouter.GET("/readyz", defaultHandler.Readyz(defaultSubscriber)) // add in v.1.0.5
 
 	// register middleware in global router & handler
 	corsConfig := cors.DefaultConfig()
@@ -181,12 +329,22 @@ func main() {
 	)
 	// run middleware after successful routing matching
 	router := globalRouter.CustomGroup("/",
+		middleware.LocalTimeFormatter(schoolLocation), // add local time in response if requested, before GinHResponseWriter (add in v.1.0.5)
 		middleware.GinHResponseWriter(),          // change ResponseWriter in *gin.Context to custom writer overriding that (add in v.1.0.3)
 		middleware.TracerSpanStarter(apiTracer),  // start, end top span of tracer & set log, tag about response (add in v.1.0.3)
 	)
 	router.Validator = validator.New()
 	redisHandler := middleware.RedisHandler(redisCli, apiTracer, redisSetTopic, redisDelTopic)
 
+	// guard of API with confirm code, lock out client IP after repeated invalid codes (add in v.1.0.5)
+	confirmCodeGuard := middleware.ConfirmCodeGuard(redisCli, middleware.ConfirmCodeGuardConfig{
+		MaxRequests:    10,
+		Window:         time.Minute,
+		MaxFailures:    5,
+		FailureWindow:  time.Minute * 15,
+		LockoutTimeout: time.Minute * 30,
+	})
+
 	// routing auth service API
 	authRouter := router.CustomGroup("/", middleware.LogEntrySetter(authLogger))
 	// auth service api for admin
@@ -248,9 +406,18 @@ func main() {
 	outingRouter.GETWithAuth("/v1/students/uuid/:student_uuid/outings", defaultHandler.GetStudentOutings, redisHandler.GetStudentOutings()...)
 	outingRouter.GETWithAuth("/v1/outings/uuid/:outing_uuid", defaultHandler.GetOutingInform, redisHandler.GetOutingInform()...)
 	outingRouter.GETWithAuth("/v1/outings/uuid/:outing_uuid/card", defaultHandler.GetCardAboutOuting, redisHandler.GetCardAboutOuting()...)
-	outingRouter.POST("/v1/outings/uuid/:outing_uuid/actions/:action", defaultHandler.TakeActionInOuting, redisHandler.TakeActionInOuting()...)
-	outingRouter.GETWithAuth("/v1/outings/with-filter", defaultHandler.GetOutingWithFilter, redisHandler.GetOutingWithFilter()...)
-	outingRouter.GET("/v1/outings/code/:OCode", defaultHandler.GetOutingByOCode)
+	outingRouter.POST("/v1/outings/uuid/:outing_uuid/actions/:action", defaultHandler.TakeActionInOuting, append([]gin.HandlerFunc{confirmCodeGuard}, redisHandler.TakeActionInOuting()...)...) // change in v.1.0.5
+	outingRouter.GETWithAuth("/v1/outings/with-filter", defaultHandler.GetOutingWithFilter, append([]gin.HandlerFunc{defaultHandler.ScopeOutingFilter}, redisHandler.GetOutingWithFilter()...)...) // change in v.1.0.5
+	outingRouter.GET("/v1/outings/code/:OCode", defaultHandler.GetOutingByOCode, confirmCodeGuard) // change in v.1.0.5
+	outingRouter.GETWithAuth("/v1/outings/stream", defaultHandler.StreamOutingEvents) // add in v.1.0.5
+	outingRouter.GETWithAuth("/v1/outings/uuid/:outing_uuid/card/qr-code", defaultHandler.GetOutingCardQRCode) // add in v.1.0.5
+	outingRouter.POSTWithAuth("/v1/outings/card/verification", defaultHandler.VerifyOutingCardQRCode) // add in v.1.0.5
+	outingRouter.GETWithAuth("/v1/outings/statistics", defaultHandler.GetOutingStatistics) // add in v.1.0.5
+	outingRouter.POSTWithAuth("/v1/outings/actions/:action", defaultHandler.TakeActionInOutings, redisHandler.TakeActionInOutings()...) // add in v.1.0.5
+	outingRouter.GETWithAuth("/v1/outings/uuid/:outing_uuid/emergency", defaultHandler.GetEmergencyOutingStatus) // add in v.1.0.5
+	outingRouter.PUTWithAuth("/v1/outings/emergency/on-duty-teachers", defaultHandler.SetOnDutyTeachers) // add in v.1.0.5
+	outingRouter.PUTWithAuth("/v1/outings/scope-exceptions/teachers/:teacher_uuid", defaultHandler.SetOutingScopeException) // add in v.1.0.5
+	outingRouter.DELETEWithAuth("/v1/outings/scope-exceptions/teachers/:teacher_uuid", defaultHandler.DeleteOutingScopeException) // add in v.1.0.5
 
 	// routing schedule service API
 	scheduleRouter := router.CustomGroup("/", middleware.LogEntrySetter(scheduleLogger))
@@ -259,17 +426,32 @@ func main() {
 	scheduleRouter.GETWithAuth("/v1/time-tables/years/:year/months/:month/days/:day", defaultHandler.GetTimeTable, redisHandler.GetTimeTable()...)
 	scheduleRouter.PATCHWithAuth("/v1/schedules/uuid/:schedule_uuid", defaultHandler.UpdateSchedule, redisHandler.UpdateSchedule()...)
 	scheduleRouter.DELETEWithAuth("/v1/schedules/uuid/:schedule_uuid", defaultHandler.DeleteSchedule, redisHandler.DeleteSchedule()...)
+	scheduleRouter.GETWithAuth("/v1/schedules/this-month", defaultHandler.GetThisMonthSchedule, append([]gin.HandlerFunc{defaultHandler.ThisMonthScheduleRequestSetter}, redisHandler.GetSchedule()...)...) // add in v.1.0.5
+	scheduleRouter.GETWithAuth("/v1/time-tables/today", defaultHandler.GetTodayTimeTable, append([]gin.HandlerFunc{defaultHandler.TodayTimeTableRequestSetter}, redisHandler.GetTimeTable()...)...) // add in v.1.0.5
+	scheduleRouter.GETWithAuth("/v1/time-tables", defaultHandler.GetTimeTablesInRange) // add in v.1.0.5
+	scheduleRouter.POSTWithAuth("/v1/schedules/imported-from/file", defaultHandler.ImportSchedules, redisHandler.ImportSchedules()...) // add in v.1.0.5
+	scheduleRouter.POSTWithAuth("/v1/schedules/series", defaultHandler.CreateScheduleSeries, redisHandler.CreateScheduleSeries()...) // add in v.1.0.5
+	scheduleRouter.GETWithAuth("/v1/schedules/series/:series_uuid", defaultHandler.GetScheduleSeries) // add in v.1.0.5
+	scheduleRouter.PATCHWithAuth("/v1/schedules/series/:series_uuid/occurrences/:date", defaultHandler.UpdateScheduleOccurrences, redisHandler.UpdateScheduleOccurrences()...) // add in v.1.0.5
+	scheduleRouter.DELETEWithAuth("/v1/schedules/series/:series_uuid/occurrences/:date", defaultHandler.DeleteScheduleOccurrences, redisHandler.DeleteScheduleOccurrences()...) // add in v.1.0.5
+	scheduleRouter.GETWithAuth("/v1/calendar/days-off", defaultHandler.GetDaysOff) // add in v.1.0.5
+	scheduleRouter.PUTWithAuth("/v1/calendar/closures/:date", defaultHandler.SetSchoolClosure, redisHandler.SetSchoolClosure()...) // add in v.1.0.5
+	scheduleRouter.DELETEWithAuth("/v1/calendar/closures/:date", defaultHandler.DeleteSchoolClosure, redisHandler.DeleteSchoolClosure()...) // add in v.1.0.5
+	scheduleRouter.POSTWithAuth("/v1/schedules/feed-token", defaultHandler.IssueScheduleFeedToken) // add in v.1.0.5
+	scheduleRouter.DELETEWithAuth("/v1/schedules/feed-token", defaultHandler.RevokeScheduleFeedToken) // add in v.1.0.5
+	scheduleRouter.GET("/v1/schedules/feeds/:token/calendar.ics", defaultHandler.GetScheduleFeed) // add in v.1.0.5
 
 	// routing announcement service API
 	announcementRouter := router.CustomGroup("/", middleware.LogEntrySetter(announcementLogger))
 	announcementRouter.POSTWithAuth("/v1/announcements", defaultHandler.CreateAnnouncement, redisHandler.CreateAnnouncement()...)
 	announcementRouter.GETWithAuth("/v1/announcements/types/:type", defaultHandler.GetAnnouncements, redisHandler.GetAnnouncements()...)
-	announcementRouter.GETWithAuth("/v1/announcements/uuid/:announcement_uuid", defaultHandler.GetAnnouncementDetail, redisHandler.GetAnnouncementDetail()...)
+	announcementRouter.GETWithAuth("/v1/announcements/uuid/:announcement_uuid", defaultHandler.GetAnnouncementDetail, append([]gin.HandlerFunc{defaultHandler.RecordAnnouncementRead}, redisHandler.GetAnnouncementDetail()...)...) // change in v.1.0.5
 	announcementRouter.PATCHWithAuth("/v1/announcements/uuid/:announcement_uuid", defaultHandler.UpdateAnnouncement, redisHandler.UpdateAnnouncement()...)
 	announcementRouter.DELETEWithAuth("/v1/announcements/uuid/:announcement_uuid", defaultHandler.DeleteAnnouncement, redisHandler.DeleteAnnouncement()...)
 	announcementRouter.GETWithAuth("/v1/students/uuid/:student_uuid/announcement-check", defaultHandler.CheckAnnouncement, redisHandler.CheckAnnouncement()...)
 	announcementRouter.GETWithAuth("/v1/announcements/types/:type/query/:search_query", defaultHandler.SearchAnnouncements, redisHandler.SearchAnnouncements()...)
 	announcementRouter.GETWithAuth("/v1/announcements/writer-uuid/:writer_uuid", defaultHandler.GetMyAnnouncements, redisHandler.GetMyAnnouncements()...)
+	announcementRouter.GETWithAuth("/v1/announcements/uuid/:announcement_uuid/read-receipts", defaultHandler.GetAnnouncementReadReceipts) // add in v.1.0.5
 
 	// routing open-api agent API
 	openApiRouter := router.CustomGroup("/", middleware.LogEntrySetter(openApiLogger))
@@ -279,6 +461,23 @@ func main() {
 	excelApiRouter := router.CustomGroup("/", middleware.LogEntrySetter(excelApiLogger))
 	excelApiRouter.POSTWithAuth("/v1/unsigned-students/parsed-by/excel", defaultHandler.AddUnsignedStudentsFromExcel)
 	excelApiRouter.POSTWithAuth("/v1/unsigned-students/parsed-by/excel/sheets/:sheet", defaultHandler.AddUnsignedStudentsFromExcel)
+	excelApiRouter.GETWithAuth("/v1/outings/exported-to/excel", defaultHandler.ExportOutingsToExcel) // add in v.1.0.5
+
+	// export in-flight, queued, completed & rejected count of bulkheads as metrics in admin API (add in v.1.0.5)
+	expvar.Publish("bulkheads", defaultHandler.BulkheadsVar())
+
+	// routing admin API to inspect & change state of gateway, all actions are audit-logged (add in v.1.0.5)
+	adminApiRouter := router.CustomGroup("/", middleware.LogEntrySetter(adminApiLogger))
+	adminApiRouter.GETWithAuth("/v1/admin/services/nodes", defaultHandler.GetServiceNodesForAdmin, middleware.AdminAuditor())
+	adminApiRouter.POSTWithAuth("/v1/admin/services/nodes/actions/refresh", defaultHandler.ChangeAllServiceNodesForAdmin, middleware.AdminAuditor())
+	adminApiRouter.POSTWithAuth("/v1/admin/nodes/id/:node_id/actions/:action", defaultHandler.TakeActionInServiceNodeForAdmin, middleware.AdminAuditor())
+	adminApiRouter.GETWithAuth("/v1/admin/breakers", defaultHandler.GetBreakersForAdmin, middleware.AdminAuditor())
+	adminApiRouter.DELETEWithAuth("/v1/admin/breakers/node-id/:node_id", defaultHandler.ResetBreakerForAdmin, middleware.AdminAuditor())
+	adminApiRouter.GETWithAuth("/v1/admin/bulkheads", defaultHandler.GetBulkheadsForAdmin, middleware.AdminAuditor())
+	adminApiRouter.GETWithAuth("/v1/admin/debug/vars", defaultHandler.GetDebugVarsForAdmin, middleware.AdminAuditor())
+	adminApiRouter.GETWithAuth("/v1/admin/caches", defaultHandler.GetCacheKeysForAdmin, middleware.AdminAuditor())
+	adminApiRouter.GETWithAuth("/v1/admin/caches/key/:key", defaultHandler.GetCacheForAdmin, middleware.AdminAuditor())
+	adminApiRouter.DELETEWithAuth("/v1/admin/caches", defaultHandler.DeleteCachesForAdmin, middleware.AdminAuditor())
 
 	// run server
 	log.Fatal(globalRouter.Run(":80"))
@@ -0,0 +1,44 @@
+// add file in v.1.0.5
+// admin_auditor.go is file that declare middleware allowing only admin & logging audit of admin action
+
+package middleware
+
+import (
+	jwtutil "gateway/tool/jwt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"regexp"
+)
+
+var adminUUIDRegex = regexp.MustCompile("^admin-\\d{12}$")
+
+// AdminAuditor have to be registered after Authenticator & LogEntrySetter, because it uses claims & log entry
+func AdminAuditor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inAdvanceClaims, _ := c.Get("Claims")
+		uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+
+		inAdvanceEntry, _ := c.Get("RequestLogEntry")
+		entry, _ := inAdvanceEntry.(*logrus.Entry)
+		entry = entry.WithFields(logrus.Fields{"user_uuid": uuidClaims.UUID, "params": c.Params, "query": c.Request.URL.RawQuery})
+
+		if !adminUUIDRegex.MatchString(uuidClaims.UUID) {
+			status, _code, msg := http.StatusForbidden, 0, "you are not admin, admin API is only for admin"
+			c.AbortWithStatusJSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Warn("admin action forbidden")
+			return
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		switch w := c.Writer.(type) {
+		case *ginHResponseWriter:
+			if w.written {
+				entry = entry.WithFields(logrus.Fields{"code": w.json["code"], "message": w.json["message"]})
+			}
+		}
+		entry.WithField("status", status).Info("admin action audit")
+	}
+}
@@ -0,0 +1,80 @@
+// add file in v.1.0.5
+// confirm_code_guard.go is file that declare middleware rate-limiting API with confirm code or parent approval token
+// client IP is locked out for a while after repeated invalid codes, to prevent brute force of confirm code
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ConfirmCodeGuardConfig struct {
+	MaxRequests    int64         // max number of requests per client IP in window
+	Window         time.Duration // window of rate limit
+	MaxFailures    int64         // max number of invalid codes per client IP before lockout
+	FailureWindow  time.Duration // window to count invalid codes
+	LockoutTimeout time.Duration // duration of lockout
+}
+
+// response status regarded as invalid confirm code or approval token
+var invalidCodeStatuses = map[int]bool{
+	http.StatusUnauthorized: true,
+	http.StatusNotFound:     true,
+}
+
+// ConfirmCodeGuard guard request with OCode uri parameter or parent action only, other requests are passed
+func ConfirmCodeGuard(cli *redis.Client, cfg ConfirmCodeGuardConfig) gin.HandlerFunc {
+	ctx := context.Background()
+
+	return func(c *gin.Context) {
+		if c.Param("OCode") == "" && !strings.HasPrefix(c.Param("action"), "parent-") {
+			c.Next()
+			return
+		}
+
+		cip := c.ClientIP()
+		lockoutKey := fmt.Sprintf("confirm-code.lockout.%s", cip)
+		if ttl, err := cli.TTL(ctx, lockoutKey).Result(); err == nil && ttl > 0 {
+			status, _code, msg := http.StatusTooManyRequests, 0, fmt.Sprintf("too many invalid codes, please try again after %s", ttl.Round(time.Second))
+			c.AbortWithStatusJSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			return
+		}
+
+		rateKey := fmt.Sprintf("confirm-code.rate.%s", cip)
+		if count, err := cli.Incr(ctx, rateKey).Result(); err == nil {
+			if count == 1 {
+				cli.Expire(ctx, rateKey, cfg.Window)
+			}
+			if count > cfg.MaxRequests {
+				status, _code, msg := http.StatusTooManyRequests, 0, fmt.Sprintf("too many requests with code, please try again after %s", cfg.Window)
+				c.AbortWithStatusJSON(status, gin.H{"status": status, "code": _code, "message": msg})
+				return
+			}
+		}
+
+		// run business logic handler
+		c.Next()
+
+		if !invalidCodeStatuses[c.Writer.Status()] {
+			return
+		}
+		failureKey := fmt.Sprintf("confirm-code.failures.%s", cip)
+		failures, err := cli.Incr(ctx, failureKey).Result()
+		if err != nil {
+			return
+		}
+		if failures == 1 {
+			cli.Expire(ctx, failureKey, cfg.FailureWindow)
+		}
+		if failures >= cfg.MaxFailures {
+			cli.Set(ctx, lockoutKey, failures, cfg.LockoutTimeout)
+			cli.Del(ctx, failureKey)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"gateway/tool/redistest"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestConfirmCodeRouter(cli *redis.Client, cfg ConfirmCodeGuardConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ConfirmCodeGuard(cli, cfg))
+
+	// confirm code "123456" is the only valid code
+	router.GET("/outings/code/:OCode", func(c *gin.Context) {
+		if c.Param("OCode") != "123456" {
+			c.JSON(http.StatusNotFound, gin.H{"status": http.StatusNotFound})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": http.StatusOK})
+	})
+	router.POST("/outings/uuid/:outing_uuid/actions/:action", func(c *gin.Context) {
+		if c.Query("token") != "valid" {
+			c.JSON(http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": http.StatusOK})
+	})
+	router.GET("/students", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"status": http.StatusNotFound})
+	})
+	return router
+}
+
+func TestConfirmCodeGuardLockout(t *testing.T) {
+	s := redistest.NewServer()
+	defer s.Close()
+	cli := redis.NewClient(&redis.Options{Addr: s.Addr})
+	defer func() { _ = cli.Close() }()
+
+	router := newTestConfirmCodeRouter(cli, ConfirmCodeGuardConfig{
+		MaxRequests:    100,
+		Window:         time.Minute,
+		MaxFailures:    3,
+		FailureWindow:  time.Minute,
+		LockoutTimeout: time.Minute * 10,
+	})
+	request := func(method, target, ip string) int {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// request without confirm code or parent action is not guarded
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/students", "10.0.0.1"))
+	}
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/outings/code/123456", "10.0.0.1"))
+
+	// invalid codes & invalid approval tokens are counted together
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/outings/code/000000", "10.0.0.1"))
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/outings/uuid/outing-1/actions/parent-approve?token=invalid", "10.0.0.1"))
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/outings/code/000001", "10.0.0.1"))
+
+	// client is locked out even with valid code, but another client is not
+	assert.Equal(t, http.StatusTooManyRequests, request(http.MethodGet, "/outings/code/123456", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/outings/code/123456", "10.0.0.2"))
+	assert.InDelta(t, time.Minute*10, s.TTL("confirm-code.lockout.10.0.0.1"), float64(time.Second))
+
+	// lockout is lifted after lockout timeout, and failures are counted from zero again
+	s.FastForward(time.Minute * 10)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/outings/code/123456", "10.0.0.1"))
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/outings/code/000000", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/outings/code/123456", "10.0.0.1"))
+}
+
+func TestConfirmCodeGuardRateLimit(t *testing.T) {
+	s := redistest.NewServer()
+	defer s.Close()
+	cli := redis.NewClient(&redis.Options{Addr: s.Addr})
+	defer func() { _ = cli.Close() }()
+
+	router := newTestConfirmCodeRouter(cli, ConfirmCodeGuardConfig{
+		MaxRequests:    2,
+		Window:         time.Minute,
+		MaxFailures:    10,
+		FailureWindow:  time.Minute,
+		LockoutTimeout: time.Minute * 10,
+	})
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/outings/code/123456", nil)
+		req.RemoteAddr = "10.0.0.1:12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusTooManyRequests, request(), "request over max requests in window is rejected")
+
+	s.FastForward(time.Minute)
+	assert.Equal(t, http.StatusOK, request(), "request is allowed in next window")
+}
@@ -0,0 +1,86 @@
+// add file in v.1.0.5
+// local_time_formatter.go is file that declare middleware adding local time formatted in school time zone to response
+// if request has local_time=true query, "{key}_local" field is added next to each unix time field such as start_time
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"strings"
+	"time"
+)
+
+// suffixes of key of field regarded as unix time in seconds
+var unixTimeKeySuffixes = []string{"_time", "_date", "_at"}
+
+// it must be registered before GinHResponseWriter, so that response cached in redis doesn't have local time field
+func LocalTimeFormatter(location *time.Location) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("local_time") == "true" {
+			c.Writer = &localTimeWriter{ResponseWriter: c.Writer, location: location}
+		}
+		c.Next()
+	}
+}
+
+type localTimeWriter struct {
+	gin.ResponseWriter
+	location *time.Location
+}
+
+// add local time fields in JSON response, response which isn't JSON object is written as it is
+func (w *localTimeWriter) Write(b []byte) (int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	resp := map[string]interface{}{}
+	if err := decoder.Decode(&resp); err != nil {
+		return w.ResponseWriter.Write(b)
+	}
+
+	formatted, err := json.Marshal(w.addLocalTime(resp))
+	if err != nil {
+		return w.ResponseWriter.Write(b)
+	}
+	if _, err = w.ResponseWriter.Write(formatted); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// add "{key}_local" field next to unix time field in value recursively
+func (w *localTimeWriter) addLocalTime(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		locals := map[string]interface{}{}
+		for key, field := range v {
+			if number, ok := field.(json.Number); ok && isUnixTimeKey(key) {
+				if unix, err := number.Int64(); err == nil && unix >= 1e9 && unix < 1e10 {
+					locals[key+"_local"] = time.Unix(unix, 0).In(w.location).Format(time.RFC3339)
+				}
+				continue
+			}
+			v[key] = w.addLocalTime(field)
+		}
+		for key, local := range locals {
+			if _, exist := v[key]; !exist {
+				v[key] = local
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = w.addLocalTime(v[i])
+		}
+	}
+	return value
+}
+
+func isUnixTimeKey(key string) bool {
+	for _, suffix := range unixTimeKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
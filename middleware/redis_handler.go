@@ -238,6 +238,88 @@ func (r *redisHandler) DeleteKeyEventPublisher(keys[] string, successStatus int)
 	}
 }
 
+// publish delete redis key event with keys per item in SucceededItems of context, set by handler processing items in bulk
+// param in key (ex. $outing_uuid) is replaced with each item, and other params are formatted with request (add in v.1.0.5)
+func (r *redisHandler) DeleteKeyEventPublisherPerItem(keys []string, param string, successStatus int) gin.HandlerFunc {
+	for _, key := range keys {
+		if key == "" {
+			systemlog.Fatalln("parameter of DeleteKeyEventPublisherPerItem to delete redis key must not be blank string")
+		}
+	}
+	ctx := context.Background()
+
+	return func(c *gin.Context) {
+		// run business logic handler
+		c.Next()
+
+		reqID := c.GetHeader("X-Request-Id")
+
+		inAdvanceTopSpan, _ := c.Get("TopSpan")
+		topSpan, _ := inAdvanceTopSpan.(opentracing.Span)
+
+		inAdvanceClaims, _ := c.Get("Claims")
+		uuidClaims, _ := inAdvanceClaims.(jwtutil.UUIDClaims)
+
+		inAdvanceReq, _ := c.Get("Request")
+
+		inAdvanceItems, _ := c.Get("SucceededItems")
+		items, _ := inAdvanceItems.([]string)
+
+		redisSpan := r.tracer.StartSpan("PublishDeleteEvent", opentracing.ChildOf(topSpan.Context())).SetTag("X-Request-Id", reqID)
+		var status int
+		switch w := c.Writer.(type) {
+		case *ginHResponseWriter:
+			status = w.status
+		default:
+			err := errors.New("unable to get response status code from default response writer")
+			redisSpan.SetTag("success", false).LogFields(log.Object("keys", keys), log.Error(err))
+			redisSpan.Finish()
+			return
+		}
+
+		if status != successStatus {
+			err := errors.New("response status code is not success status code to delete key in redis")
+			redisSpan.SetTag("success", false).LogFields(log.Object("keys", keys), log.Error(err))
+			redisSpan.Finish()
+			return
+		}
+
+		var redisKeys []string
+		for _, item := range items {
+			for _, key := range keys {
+				key = strings.ReplaceAll(key, "$"+param, item)
+				redisKey, err := r.formatKeyWithRequest(key, c, inAdvanceReq, uuidClaims)
+				if err != nil {
+					redisSpan.SetTag("success", false).LogFields(log.String("key", key), log.Error(err))
+					redisSpan.Finish()
+					return
+				}
+				redisKeys = append(redisKeys, redisKey)
+			}
+		}
+
+		// remove duplicated keys not related to item, such as outings.filter
+		published := map[string]bool{}
+		for _, redisKey := range redisKeys {
+			if published[redisKey] {
+				continue
+			}
+			published[redisKey] = true
+
+			if _, err := r.client.Publish(ctx, r.delTopic, redisKey).Result(); err != nil {
+				redisSpan.SetTag("success", false).LogFields(log.String("topic", r.delTopic),
+					log.String("key", redisKey), log.Error(err))
+				redisSpan.Finish()
+				return
+			}
+		}
+
+		redisSpan.LogFields(log.String("topic", r.delTopic), log.Object("keys", redisKeys))
+		redisSpan.Finish()
+		return
+	}
+}
+
 func (r *redisHandler) formatKeyWithRequest(key string, c *gin.Context, req interface{}, claims jwtutil.UUIDClaims) (redisKey string, err error) {
 	var reqValue reflect.Value
 	if req != nil {
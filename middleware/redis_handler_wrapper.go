@@ -33,6 +33,12 @@ func (r *redisHandler) TakeActionInOuting() []gin.HandlerFunc {
 	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
 }
 
+// add in v.1.0.5
+func (r *redisHandler) TakeActionInOutings() []gin.HandlerFunc {
+	redisDelKeys := []string{"outings.$outing_uuid", "outings.$outing_uuid.card", "students.{outings.$outing_uuid.student_uuid}.outings", "outings.filter"}
+	return []gin.HandlerFunc{r.DeleteKeyEventPublisherPerItem(redisDelKeys, "outing_uuid", http.StatusOK)}
+}
+
 func (r *redisHandler) GetOutingWithFilter() []gin.HandlerFunc {
 	redisSetKey := "outings.filter.start.$Start.count.$Count.status.$Status.grade.$Grade.group.$Group.floor.$Floor"
 	return r.ResponderAndSetEventPublisher(redisSetKey, http.StatusOK)
@@ -63,6 +69,39 @@ func (r *redisHandler) DeleteSchedule() []gin.HandlerFunc {
 	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
 }
 
+// delete schedules once after every occurrence in series is created, updated or deleted (add in v.1.0.5)
+func (r *redisHandler) CreateScheduleSeries() []gin.HandlerFunc {
+	redisDelKeys := []string{"schedules"}
+	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusCreated)}
+}
+
+func (r *redisHandler) UpdateScheduleOccurrences() []gin.HandlerFunc {
+	redisDelKeys := []string{"schedules"}
+	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
+}
+
+func (r *redisHandler) DeleteScheduleOccurrences() []gin.HandlerFunc {
+	redisDelKeys := []string{"schedules"}
+	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
+}
+
+// delete schedules & timetables of every student, because days off are overlaid on them (add in v.1.0.5)
+func (r *redisHandler) SetSchoolClosure() []gin.HandlerFunc {
+	redisDelKeys := []string{"schedules", "students.*.timetable"}
+	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
+}
+
+func (r *redisHandler) DeleteSchoolClosure() []gin.HandlerFunc {
+	redisDelKeys := []string{"schedules", "students.*.timetable"}
+	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
+}
+
+// delete schedules only once after import, handler responses 201 only if at least one schedule is created (add in v.1.0.5)
+func (r *redisHandler) ImportSchedules() []gin.HandlerFunc {
+	redisDelKeys := []string{"schedules"}
+	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusCreated)}
+}
+
 func (r *redisHandler) CreateAnnouncement() []gin.HandlerFunc {
 	redisDelKeys := []string{"announcements.uuid.*.types.$Type", "students.*.announcement-check", "writers.$TokenUUID.announcements"}
 	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusCreated)}
@@ -85,9 +124,11 @@ func (r *redisHandler) UpdateAnnouncement() []gin.HandlerFunc {
 	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
 }
 
+// read receipts of announcement are deleted with announcement (change in v.1.0.5)
 func (r *redisHandler) DeleteAnnouncement() []gin.HandlerFunc {
 	redisDelKeys := []string{"announcements.uuid.*.types.{announcements.$announcement_uuid.type}",
-		"announcements.$announcement_uuid", "students.*.announcement-check", "writers.$TokenUUID.announcements"}
+		"announcements.$announcement_uuid", "students.*.announcement-check", "writers.$TokenUUID.announcements",
+		"announcement-reads.$announcement_uuid"}
 	return []gin.HandlerFunc{r.DeleteKeyEventPublisher(redisDelKeys, http.StatusOK)}
 }
 
@@ -60,7 +60,9 @@ func (r *requestValidator) RequestValidator(h gin.HandlerFunc) gin.HandlerFunc {
 		case *entity.GetClubsSortByUpdateTimeRequest, *entity.GetRecruitmentsSortByCreateTimeRequest, *entity.GetStudentOutingsRequest,
 			*entity.GetOutingWithFilterRequest, *entity.GetAnnouncementsRequest, *entity.GetPlaceWithNaverOpenAPIRequest,
 			*entity.GetStudentUUIDsWithInformRequest, *entity.GetTeacherUUIDsWithInformRequest, *entity.GetParentUUIDsWithInformRequest,
-			*entity.GetMyAnnouncementsRequest, *entity.SearchAnnouncementsRequest, *entity.SendJoinSMSToUnsignedStudentsRequest:
+			*entity.GetMyAnnouncementsRequest, *entity.SearchAnnouncementsRequest, *entity.SendJoinSMSToUnsignedStudentsRequest,
+			*entity.ExportOutingsToExcelRequest, *entity.GetOutingCardQRCodeRequest, *entity.GetOutingStatisticsRequest,
+			*entity.GetTimeTablesInRangeRequest, *entity.DeleteScheduleOccurrencesRequest, *entity.GetDaysOffRequest:
 				if err := c.ShouldBindQuery(req); err != nil {
 					respFor400["code"] = code.FailToBindRequestToStruct
 					respFor400["message"] = fmt.Sprintf("failed to bind query parameter in request into golang struct, err: %v", err)
@@ -8,11 +8,12 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/go-redis/redis/v8"
 	log "github.com/micro/go-micro/v2/logger"
+	"sync/atomic"
 )
 
 var (
 	awsSession *session.Session
-	redisCli *redis.Client
+	redisCli   *redis.Client
 )
 
 func SetAwsSession(s *session.Session) {
@@ -27,6 +28,7 @@ type _default struct {
 	awsSession  *session.Session
 	listeners   []func()
 	beforeStart []func()
+	alive       int32 // number of listeners running now (add in v.1.0.5)
 }
 
 type FieldSetter func(*_default)
@@ -66,10 +68,27 @@ func (s *_default) StartListening() (_ error) {
 	for _, before := range s.beforeStart {
 		before()
 	}
-	
+
 	log.Info("Default subscriber start listening!!")
 	for _, listener := range s.listeners {
-		go listener()
+		atomic.AddInt32(&s.alive, 1)
+		go s.runListener(listener)
 	}
 	return
 }
+
+// run listener & decrease alive listener count when listener returned or panicked (add in v.1.0.5)
+func (s *_default) runListener(listener func()) {
+	defer func() {
+		atomic.AddInt32(&s.alive, -1)
+		if r := recover(); r != nil {
+			log.Errorf("listener of default subscriber panicked, recover: %v", r)
+		}
+	}()
+	listener()
+}
+
+// return number of listeners running now & number of all registered listeners (add in v.1.0.5)
+func (s *_default) AliveListeners() (alive, total int) {
+	return int(atomic.LoadInt32(&s.alive)), len(s.listeners)
+}
@@ -20,7 +20,11 @@ func RedisListener(topic string, handler redisMsgHandler, chlSize int) func() {
 
 	return func() {
 		for {
-			pubMsg := <- pubChl
+			pubMsg, ok := <- pubChl
+			if !ok {
+				log.Errorf("redis message channel was closed, stop listening, topic: %s", topic)
+				return
+			}
 			go func(msg *redis.Message) {
 				if err := handler(msg); err != nil {
 					log.Errorf("some error occurs while handling redis message, topic: %s, err: %v", topic, err)
@@ -16,3 +16,11 @@ func GetAndFatalIfNotExits(name string) (env string) {
 	}
 	return
 }
+
+// get environment variable from local & return default value if not exist (add in v.1.0.5)
+func GetOrDefault(name, defaultValue string) (env string) {
+	if env = os.Getenv(name); env == "" {
+		env = defaultValue
+	}
+	return
+}
@@ -0,0 +1,199 @@
+// Add package in v.1.0.5
+// this package is used for utility to encode data in QR code & render it to image without third-party service
+// encode.go is file to declare encoding data to codewords of QR code in byte mode with error correction level M
+
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// QRCode is encoded QR code, modules[y][x] is true if module is dark
+type QRCode struct {
+	Version   int
+	Size      int
+	modules   [][]bool
+	functions [][]bool // true if module is function pattern, not masked
+}
+
+// block structure of error correction level M per version
+type versionInfo struct {
+	totalCodewords int
+	eccPerBlock    int
+	blocks         []int // number of data codewords per block
+	alignments     []int // center positions of alignment patterns
+}
+
+// support version 1 ~ 10, which can hold up to 213 bytes in error correction level M
+var versionInfos = []versionInfo{
+	{},
+	{26, 10, []int{16}, nil},
+	{44, 16, []int{28}, []int{6, 18}},
+	{70, 26, []int{44}, []int{6, 22}},
+	{100, 18, []int{32, 32}, []int{6, 26}},
+	{134, 24, []int{43, 43}, []int{6, 30}},
+	{172, 16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{196, 18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{242, 22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{292, 22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{346, 26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+var ErrDataTooLong = errors.New("data is too long to encode in supported version of QR code")
+
+// encode data in QR code with the smallest version that can hold data
+func Encode(data []byte) (qr *QRCode, err error) {
+	version := 0
+	for v := 1; v < len(versionInfos); v++ {
+		if len(data) <= capacityOf(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		err = ErrDataTooLong
+		return
+	}
+
+	codewords := addErrorCorrection(version, dataCodewords(version, data))
+	qr = newQRCode(version)
+	qr.drawCodewords(codewords)
+	qr.applyBestMask()
+	return
+}
+
+// return max number of bytes encoded in version
+func capacityOf(version int) int {
+	return (dataCodewordsOf(version)*8 - 4 - charCountBits(version)) / 8
+}
+
+func dataCodewordsOf(version int) (sum int) {
+	for _, n := range versionInfos[version].blocks {
+		sum += n
+	}
+	return
+}
+
+// bits of character count indicator in byte mode
+func charCountBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// bitBuffer is buffer appending bits in big endian
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>uint(i))&1 == 1)
+	}
+}
+
+// make data codewords with mode indicator, character count, data, terminator & pad bytes
+func dataCodewords(version int, data []byte) (codewords []byte) {
+	capacityBits := dataCodewordsOf(version) * 8
+
+	var bits bitBuffer
+	bits.append(0x4, 4) // byte mode
+	bits.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	if terminator := capacityBits - len(bits); terminator < 4 {
+		bits.append(0, terminator)
+	} else {
+		bits.append(0, 4)
+	}
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacityBits; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords = make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return
+}
+
+// split data codewords in blocks, add error correction codewords per block & interleave them
+func addErrorCorrection(version int, data []byte) (codewords []byte) {
+	info := versionInfos[version]
+	divisor := reedSolomonDivisor(info.eccPerBlock)
+
+	var dataBlocks, eccBlocks [][]byte
+	maxDataLen := 0
+	for _, n := range info.blocks {
+		block := data[:n]
+		data = data[n:]
+		dataBlocks = append(dataBlocks, block)
+		eccBlocks = append(eccBlocks, reedSolomonRemainder(block, divisor))
+		if n > maxDataLen {
+			maxDataLen = n
+		}
+	}
+
+	for i := 0; i < maxDataLen; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				codewords = append(codewords, block[i])
+			}
+		}
+	}
+	for i := 0; i < info.eccPerBlock; i++ {
+		for _, block := range eccBlocks {
+			codewords = append(codewords, block[i])
+		}
+	}
+
+	if len(codewords) != info.totalCodewords {
+		panic(fmt.Sprintf("invalid number of codewords in version %d, %d", version, len(codewords)))
+	}
+	return
+}
+
+// return coefficients of generator polynomial of degree, except for leading term
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// return remainder of data polynomial divided by generator polynomial
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// multiply in GF(2^8) with primitive polynomial 0x11D
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
@@ -0,0 +1,280 @@
+// Add file in v.1.0.5
+// matrix.go is file to declare drawing function patterns & codewords in matrix of QR code and choosing mask
+
+package qrcode
+
+func newQRCode(version int) (qr *QRCode) {
+	size := version*4 + 17
+	qr = &QRCode{Version: version, Size: size}
+	qr.modules = make([][]bool, size)
+	for i := range qr.modules {
+		qr.modules[i] = make([]bool, size)
+	}
+	qr.functions = make([][]bool, size)
+	for i := range qr.functions {
+		qr.functions[i] = make([]bool, size)
+	}
+
+	qr.drawFunctionPatterns()
+	return
+}
+
+// check if module in (x, y) is dark
+func (qr *QRCode) Dark(x, y int) bool {
+	return qr.modules[y][x]
+}
+
+func (qr *QRCode) setFunction(x, y int, dark bool) {
+	qr.modules[y][x] = dark
+	qr.functions[y][x] = true
+}
+
+// draw finder, timing, alignment patterns & reserve area of format, version information
+func (qr *QRCode) drawFunctionPatterns() {
+	for i := 0; i < qr.Size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	qr.drawFinderPattern(3, 3)
+	qr.drawFinderPattern(qr.Size-4, 3)
+	qr.drawFinderPattern(3, qr.Size-4)
+
+	alignments := versionInfos[qr.Version].alignments
+	last := len(alignments) - 1
+	for i, y := range alignments {
+		for j, x := range alignments {
+			// skip alignment patterns overlapped with finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			qr.drawAlignmentPattern(x, y)
+		}
+	}
+
+	qr.drawFormatBits(0)
+	qr.drawVersionBits()
+}
+
+// draw finder pattern with separator, centered in (x, y)
+func (qr *QRCode) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= qr.Size || yy < 0 || yy >= qr.Size {
+				continue
+			}
+			dist := maxInt(absInt(dx), absInt(dy))
+			qr.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// draw alignment pattern centered in (x, y)
+func (qr *QRCode) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			qr.setFunction(x+dx, y+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+		}
+	}
+}
+
+// draw two copies of format information with error correction level M & mask
+func (qr *QRCode) drawFormatBits(mask int) {
+	data := mask // bits of error correction level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bitOf(bits, i))
+	}
+	qr.setFunction(8, 7, bitOf(bits, 6))
+	qr.setFunction(8, 8, bitOf(bits, 7))
+	qr.setFunction(7, 8, bitOf(bits, 8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bitOf(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.setFunction(qr.Size-1-i, 8, bitOf(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, qr.Size-15+i, bitOf(bits, i))
+	}
+	qr.setFunction(8, qr.Size-8, true) // dark module
+}
+
+// draw two copies of version information, only in version 7 or higher
+func (qr *QRCode) drawVersionBits() {
+	if qr.Version < 7 {
+		return
+	}
+	rem := qr.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := qr.Version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a, b := qr.Size-11+i%3, i/3
+		qr.setFunction(a, b, bitOf(bits, i))
+		qr.setFunction(b, a, bitOf(bits, i))
+	}
+}
+
+// draw codewords in zigzag order from bottom right, skipping function patterns
+func (qr *QRCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := qr.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip vertical timing pattern
+		}
+		for vert := 0; vert < qr.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if upward := (right+1)&2 == 0; upward {
+					y = qr.Size - 1 - vert
+				}
+				if qr.functions[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				qr.modules[y][x] = bitOf(int(codewords[i/8]), 7-i%8)
+				i++
+			}
+		}
+	}
+}
+
+// mask conditions, module is inverted if condition is true
+var maskConditions = []func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+// apply mask with the lowest penalty score & draw format information of the mask
+func (qr *QRCode) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := range maskConditions {
+		qr.applyMask(mask)
+		qr.drawFormatBits(mask)
+		if penalty := qr.penaltyScore(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		qr.applyMask(mask) // undo mask, because mask is XOR
+	}
+	qr.applyMask(best)
+	qr.drawFormatBits(best)
+}
+
+func (qr *QRCode) applyMask(mask int) {
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if !qr.functions[y][x] && maskConditions[mask](x, y) {
+				qr.modules[y][x] = !qr.modules[y][x]
+			}
+		}
+	}
+}
+
+// pattern similar to finder pattern, penalized in rule 3
+var finderLikePatterns = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// calculate penalty score with 4 rules in specification
+func (qr *QRCode) penaltyScore() (score int) {
+	size := qr.Size
+	line := make([]bool, size)
+	for _, vertical := range []bool{false, true} {
+		for i := 0; i < size; i++ {
+			for j := 0; j < size; j++ {
+				if vertical {
+					line[j] = qr.modules[j][i]
+				} else {
+					line[j] = qr.modules[i][j]
+				}
+			}
+
+			// rule 1: run of five or more same color modules
+			run := 1
+			for j := 1; j <= size; j++ {
+				if j < size && line[j] == line[j-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+
+			// rule 3: pattern similar to finder pattern
+			for j := 0; j+11 <= size; j++ {
+				for _, pattern := range finderLikePatterns {
+					if equalBools(line[j:j+11], pattern) {
+						score += 40
+					}
+				}
+			}
+		}
+	}
+
+	// rule 2: 2x2 block of same color modules
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if qr.modules[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				c := qr.modules[y][x]
+				if c == qr.modules[y][x+1] && c == qr.modules[y+1][x] && c == qr.modules[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+
+	// rule 4: proportion of dark modules far from 50%
+	percent := dark * 100 / (size * size)
+	score += absInt(percent-50) / 5 * 10
+	return
+}
+
+func bitOf(value, i int) bool {
+	return (value>>uint(i))&1 == 1
+}
+
+func equalBools(a, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,125 @@
+package qrcode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// capacity, total codewords & ecc codewords per block in error correction level M (ISO/IEC 18004 table 7 & 9)
+func TestVersionInfos(t *testing.T) {
+	for _, c := range []struct {
+		version, capacity, totalCodewords, eccPerBlock, blockNum int
+	}{
+		{1, 14, 26, 10, 1},
+		{2, 26, 44, 16, 1},
+		{3, 42, 70, 26, 1},
+		{4, 62, 100, 18, 2},
+		{5, 84, 134, 24, 2},
+		{6, 106, 172, 16, 4},
+		{7, 122, 196, 18, 4},
+		{8, 152, 242, 22, 4},
+		{9, 180, 292, 22, 5},
+		{10, 213, 346, 26, 5},
+	} {
+		info := versionInfos[c.version]
+		assert.Equalf(t, c.capacity, capacityOf(c.version), "capacity of version %d", c.version)
+		assert.Equalf(t, c.totalCodewords, info.totalCodewords, "total codewords of version %d", c.version)
+		assert.Equalf(t, c.eccPerBlock, info.eccPerBlock, "ecc codewords per block of version %d", c.version)
+		assert.Equalf(t, c.blockNum, len(info.blocks), "number of blocks of version %d", c.version)
+		assert.Equalf(t, c.totalCodewords, dataCodewordsOf(c.version)+c.eccPerBlock*c.blockNum, "codewords of version %d", c.version)
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	for _, c := range []struct {
+		length  int
+		version int
+		err     error
+	}{
+		{0, 1, nil},
+		{14, 1, nil},
+		{15, 2, nil},
+		{106, 6, nil},
+		{107, 7, nil},
+		{213, 10, nil},
+		{214, 0, ErrDataTooLong},
+	} {
+		qr, err := Encode(bytes.Repeat([]byte("a"), c.length))
+		assert.Equalf(t, c.err, err, "error of data length %d", c.length)
+		if err == nil {
+			assert.Equalf(t, c.version, qr.Version, "version of data length %d", c.length)
+			assert.Equalf(t, c.version*4+17, qr.Size, "size of data length %d", c.length)
+		}
+	}
+}
+
+func TestDataCodewords(t *testing.T) {
+	// "A" in byte mode: 0100 | 00000001 | 01000001 | 0000, and pad bytes 0xEC, 0x11 alternately
+	expected := []byte{0x40, 0x14, 0x10, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC}
+	assert.Equal(t, expected, dataCodewords(1, []byte("A")))
+}
+
+func TestReedSolomon(t *testing.T) {
+	// data & ecc codewords of "HELLO WORLD" in version 1-M, well known example of QR code
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	assert.Equal(t, ecc, reedSolomonRemainder(data, reedSolomonDivisor(10)))
+	assert.Equal(t, append(data, ecc...), addErrorCorrection(1, data))
+}
+
+func TestGFMultiply(t *testing.T) {
+	for _, c := range []struct {
+		x, y, expected byte
+	}{
+		{0, 0x53, 0},
+		{1, 0x53, 0x53},
+		{0x02, 0x80, 0x1D},
+		{0x53, 0xCA, 0x8F},
+	} {
+		assert.Equalf(t, c.expected, gfMultiply(c.x, c.y), "%#x * %#x", c.x, c.y)
+		assert.Equalf(t, c.expected, gfMultiply(c.y, c.x), "%#x * %#x", c.y, c.x)
+	}
+}
+
+// format information of error correction level M per mask (ISO/IEC 18004 table C.1)
+func TestFormatBits(t *testing.T) {
+	expected := []int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+	for mask, bits := range expected {
+		qr := newQRCode(1)
+		qr.drawFormatBits(mask)
+
+		// read second copy, bit 0 ~ 7 is in bottom row of upper right & bit 8 ~ 14 is in column of lower left
+		read := 0
+		for i := 0; i < 8; i++ {
+			if qr.Dark(qr.Size-1-i, 8) {
+				read |= 1 << uint(i)
+			}
+		}
+		for i := 8; i < 15; i++ {
+			if qr.Dark(8, qr.Size-15+i) {
+				read |= 1 << uint(i)
+			}
+		}
+		assert.Equalf(t, bits, read, "format bits of mask %d", mask)
+	}
+}
+
+// version information (ISO/IEC 18004 table D.1)
+func TestVersionBits(t *testing.T) {
+	for version, bits := range map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3} {
+		qr := newQRCode(version)
+		read, transposed := 0, 0
+		for i := 0; i < 18; i++ {
+			a, b := qr.Size-11+i%3, i/3
+			if qr.Dark(a, b) {
+				read |= 1 << uint(i)
+			}
+			if qr.Dark(b, a) {
+				transposed |= 1 << uint(i)
+			}
+		}
+		assert.Equalf(t, bits, read, "version bits of version %d", version)
+		assert.Equalf(t, bits, transposed, "transposed version bits of version %d", version)
+	}
+}
@@ -0,0 +1,71 @@
+// Add file in v.1.0.5
+// render.go is file to declare rendering QR code to PNG or SVG image with quiet zone
+
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// width of quiet zone around QR code in modules, required by specification
+const quietZone = 4
+
+// render QR code to PNG image, each module is drawn in scale x scale pixels
+func (qr *QRCode) PNG(scale int) (b []byte, err error) {
+	if scale < 1 {
+		scale = 1
+	}
+	length := (qr.Size + quietZone*2) * scale
+	img := image.NewGray(image.Rect(0, 0, length, length))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if !qr.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+quietZone)*scale+dx, (y+quietZone)*scale+dy, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err = png.Encode(&buf, img); err != nil {
+		return
+	}
+	b = buf.Bytes()
+	return
+}
+
+// render QR code to SVG image, dark modules are drawn in one path
+func (qr *QRCode) SVG(scale int) []byte {
+	if scale < 1 {
+		scale = 1
+	}
+	length := qr.Size + quietZone*2
+
+	buf := bytes.Buffer{}
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		length*scale, length*scale, length, length) + "\n")
+	buf.WriteString(`<rect width="100%" height="100%" fill="#FFFFFF"/>` + "\n")
+	buf.WriteString(`<path fill="#000000" d="`)
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if qr.modules[y][x] {
+				buf.WriteString(fmt.Sprintf("M%d,%dh1v1h-1z", x+quietZone, y+quietZone))
+			}
+		}
+	}
+	buf.WriteString(`"/>` + "\n</svg>\n")
+	return buf.Bytes()
+}
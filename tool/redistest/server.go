@@ -0,0 +1,394 @@
+// Add package in v.1.0.5
+// this package is used for utility to run in-memory redis server speaking RESP in tests, like httptest for http
+// server.go is file to declare server handling string commands, expiration & lua scripts registered as go function
+
+package redistest
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScriptFunc is go function run instead of lua script, it must behave same as the script
+type ScriptFunc func(s *Server, keys, args []string) interface{}
+
+// status reply, returned as simple string instead of bulk string
+type status string
+
+// Server is in-memory redis server supporting commands used in gateway, only string values are supported
+type Server struct {
+	Addr string
+
+	listener net.Listener
+	mutex    sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	scripts  map[string]ScriptFunc
+	offset   time.Duration
+	conns    map[net.Conn]bool
+	handlers sync.WaitGroup
+}
+
+// start server listening on random local port, caller should call Close when finished
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("redistest: failed to listen on a port, err: %v", err))
+	}
+
+	s := &Server{
+		Addr:     listener.Addr().String(),
+		listener: listener,
+		values:   map[string]string{},
+		expires:  map[string]time.Time{},
+		scripts:  map[string]ScriptFunc{},
+		conns:    map[net.Conn]bool{},
+	}
+	go s.serve()
+	return s
+}
+
+// close listener & every connection, and wait for handlers of connections to return
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.mutex.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mutex.Unlock()
+	s.handlers.Wait()
+}
+
+// register go function run with EVAL or EVALSHA of lua script having sha1 hash
+func (s *Server) Script(sha string, fn ScriptFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.scripts[sha] = fn
+}
+
+// move clock of server forward, so that keys expire without waiting
+func (s *Server) FastForward(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.offset += d
+}
+
+// return value of key & whether it exists
+func (s *Server) Get(key string) (value string, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.get(key)
+}
+
+// set value of key, key is persisted if ttl is 0
+func (s *Server) Set(key, value string, ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(key, value, ttl)
+}
+
+// delete key & return whether it existed
+func (s *Server) Del(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.del(key)
+}
+
+// return time to live of key, 0 is returned if key doesn't exist or has no expiration
+func (s *Server) TTL(key string) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.get(key); !ok {
+		return 0
+	}
+	if expireAt, ok := s.expires[key]; ok {
+		return expireAt.Sub(s.now())
+	}
+	return 0
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+func (s *Server) get(key string) (string, bool) {
+	if expireAt, ok := s.expires[key]; ok && !s.now().Before(expireAt) {
+		delete(s.values, key)
+		delete(s.expires, key)
+	}
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *Server) set(key, value string, ttl time.Duration) {
+	s.values[key] = value
+	delete(s.expires, key)
+	if ttl > 0 {
+		s.expires[key] = s.now().Add(ttl)
+	}
+}
+
+func (s *Server) del(key string) bool {
+	_, ok := s.get(key)
+	delete(s.values, key)
+	delete(s.expires, key)
+	return ok
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns[conn] = true
+		s.mutex.Unlock()
+		s.handlers.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.handlers.Done()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		_ = conn.Close()
+	}()
+
+	reader, writer := bufio.NewReader(conn), bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		writeReply(writer, s.exec(args))
+		if err = writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// execute command & return reply, lock is released before running script function
+func (s *Server) exec(args []string) interface{} {
+	if len(args) == 0 {
+		return errors.New("ERR empty command")
+	}
+
+	name := strings.ToLower(args[0])
+	if name == "eval" || name == "evalsha" {
+		return s.eval(name, args[1:])
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case name == "ping":
+		return status("PONG")
+	case name == "get" && len(args) == 2:
+		if value, ok := s.get(args[1]); ok {
+			return value
+		}
+		return nil
+	case name == "set" && len(args) >= 3:
+		return s.execSet(args[1], args[2], args[3:])
+	case name == "del" && len(args) >= 2:
+		deleted := 0
+		for _, key := range args[1:] {
+			if s.del(key) {
+				deleted++
+			}
+		}
+		return deleted
+	case name == "exists" && len(args) >= 2:
+		exists := 0
+		for _, key := range args[1:] {
+			if _, ok := s.get(key); ok {
+				exists++
+			}
+		}
+		return exists
+	case name == "incr" && len(args) == 2:
+		value, _ := s.get(args[1])
+		if value == "" {
+			value = "0"
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("ERR value is not an integer or out of range")
+		}
+		count++
+		s.values[args[1]] = strconv.FormatInt(count, 10)
+		return count
+	case (name == "expire" || name == "pexpire") && len(args) == 3:
+		ttl, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return errors.New("ERR value is not an integer or out of range")
+		}
+		if _, ok := s.get(args[1]); !ok {
+			return 0
+		}
+		unit := time.Second
+		if name == "pexpire" {
+			unit = time.Millisecond
+		}
+		s.expires[args[1]] = s.now().Add(time.Duration(ttl) * unit)
+		return 1
+	case (name == "ttl" || name == "pttl") && len(args) == 2:
+		if _, ok := s.get(args[1]); !ok {
+			return -2
+		}
+		expireAt, ok := s.expires[args[1]]
+		if !ok {
+			return -1
+		}
+		if name == "pttl" {
+			return int64(expireAt.Sub(s.now()) / time.Millisecond)
+		}
+		return int64((expireAt.Sub(s.now()) + time.Second/2) / time.Second)
+	}
+	return errors.New(fmt.Sprintf("ERR unknown command or wrong number of arguments for '%s'", args[0]))
+}
+
+// handle SET with EX, PX, NX & XX options
+func (s *Server) execSet(key, value string, options []string) interface{} {
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 0; i < len(options); i++ {
+		switch option := strings.ToLower(options[i]); option {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "ex", "px":
+			if i+1 >= len(options) {
+				return errors.New("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(options[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return errors.New("ERR invalid expire time in set")
+			}
+			if ttl = time.Duration(n) * time.Millisecond; option == "ex" {
+				ttl = time.Duration(n) * time.Second
+			}
+			i++
+		default:
+			return errors.New("ERR syntax error")
+		}
+	}
+
+	_, exists := s.get(key)
+	if (nx && exists) || (xx && !exists) {
+		return nil
+	}
+	s.set(key, value, ttl)
+	return status("OK")
+}
+
+// run script function registered for script or sha, NOSCRIPT error is returned if not registered
+func (s *Server) eval(name string, args []string) interface{} {
+	if len(args) < 2 {
+		return errors.New(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+	}
+	sha := args[0]
+	if name == "eval" {
+		sha = scriptSHA(args[0])
+	}
+	numKeys, err := strconv.Atoi(args[1])
+	if err != nil || numKeys < 0 || numKeys > len(args)-2 {
+		return errors.New("ERR Number of keys can't be greater than number of args")
+	}
+
+	s.mutex.Lock()
+	fn, ok := s.scripts[sha]
+	s.mutex.Unlock()
+	if !ok {
+		return errors.New("NOSCRIPT No matching script. Please use EVAL.")
+	}
+	return fn(s, args[2:2+numKeys], args[2+numKeys:])
+}
+
+func scriptSHA(src string) string {
+	sum := sha1.Sum([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+// read command sent as array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return nil, errors.New(fmt.Sprintf("unexpected command line: %q", line))
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if line, err = readLine(reader); err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New(fmt.Sprintf("unexpected argument line: %q", line))
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func writeReply(writer *bufio.Writer, reply interface{}) {
+	switch reply := reply.(type) {
+	case nil:
+		_, _ = writer.WriteString("$-1\r\n")
+	case status:
+		_, _ = fmt.Fprintf(writer, "+%s\r\n", reply)
+	case string:
+		_, _ = fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(reply), reply)
+	case int:
+		_, _ = fmt.Fprintf(writer, ":%d\r\n", reply)
+	case int64:
+		_, _ = fmt.Fprintf(writer, ":%d\r\n", reply)
+	case bool:
+		if reply {
+			_, _ = writer.WriteString(":1\r\n")
+		} else {
+			_, _ = writer.WriteString(":0\r\n")
+		}
+	case error:
+		_, _ = fmt.Fprintf(writer, "-%s\r\n", reply.Error())
+	default:
+		_, _ = fmt.Fprintf(writer, "-ERR unsupported reply type %T\r\n", reply)
+	}
+}